@@ -1,12 +1,13 @@
 package auth
 
 import (
+	"bookService/health"
+	"bookService/logging"
 	"bookService/model"
 	"bookService/store"
-	"crypto/ecdsa"
-	"crypto/elliptic"
-	"crypto/rand"
-	"log"
+	"bookService/tracing"
+	"context"
+	"fmt"
 	"net/http"
 	"time"
 
@@ -16,15 +17,21 @@ import (
 )
 
 const (
+	// AccessTokenTTL and RefreshTokenTTL are the default token lifetimes,
+	// see SetTokenTTLs.
 	AccessTokenTTL  = time.Hour * 1
 	RefreshTokenTTL = time.Hour * 24 * 7
 	StringsNumber   = 2
+
+	ClaimsKey = "claims"
+	UserKey   = "user"
 )
 
 type BaseClaims struct {
 	jwt.StandardClaims
-	ID   uint64 `json:"id"`
-	Role uint64 `json:"role"`
+	ID       uint64     `json:"id"`
+	Role     model.Role `json:"role"`
+	FamilyID string     `json:"family_id,omitempty"`
 }
 
 type AccessClaims struct {
@@ -43,130 +50,369 @@ type Tokens struct {
 	Refresh string `json:"refreshToken"`
 }
 
+// SessionInfo describes the device a session was started from.
+type SessionInfo struct {
+	UserAgent string
+	IP        string
+}
+
 type Middleware struct {
-	atKey *ecdsa.PrivateKey
-	rtKey *ecdsa.PrivateKey
-	mongo *store.MongoStore
+	atKeys     *KeySet
+	rtKeys     *KeySet
+	store      store.Store
+	accessTTL  time.Duration
+	refreshTTL time.Duration
 }
 
 type AuthMiddleware interface {
 	Authorize(c *gin.Context)
-	CreateTokens(id uint64) (*Tokens, error)
-	Refresh(tokens Tokens) (*Tokens, error)
+	RequireRole(roles ...model.Role) gin.HandlerFunc
+	StartSession(ctx context.Context, user model.User, info SessionInfo) (*Tokens, error)
+	Refresh(ctx context.Context, refreshToken string) (*Tokens, uint64, error)
+	Logout(ctx context.Context, claims *AccessClaims) error
+	LogoutAll(ctx context.Context, claims *AccessClaims) error
+	RevokeSession(ctx context.Context, userID uint64, sessionID string) error
+	RevokeUserSessions(ctx context.Context, userID uint64) error
 	ExtractToken(r *http.Request) string
-	Validate(raw string) (*AccessClaims, error)
-	GetUserID(token string) (uint64, error)
+	Validate(ctx context.Context, raw string) (*AccessClaims, error)
 }
 
-func NewAuthMiddleware(atKey, rtKey *ecdsa.PrivateKey, mongo *store.MongoStore) *Middleware {
+func NewAuthMiddleware(atKeys, rtKeys *KeySet, store store.Store) *Middleware {
 	var middleware = &Middleware{
-		atKey: atKey,
-		rtKey: rtKey,
-		mongo: mongo,
+		atKeys:     atKeys,
+		rtKeys:     rtKeys,
+		store:      store,
+		accessTTL:  AccessTokenTTL,
+		refreshTTL: RefreshTokenTTL,
 	}
 
 	return middleware
 }
 
+// SetTokenTTLs changes how long the tokens issued from now on are valid.
+func (m *Middleware) SetTokenTTLs(access, refresh time.Duration) {
+	m.accessTTL = access
+	m.refreshTTL = refresh
+}
+
+// Checks reports the middleware unready while either key set has no active
+// signing key.
+func (m *Middleware) Checks() []health.Check {
+	return []health.Check{
+		{Name: "signing_keys", Probe: func(context.Context) error {
+			if _, err := m.atKeys.Current(); err != nil {
+				return fmt.Errorf("access keys: %w", err)
+			}
+			if _, err := m.rtKeys.Current(); err != nil {
+				return fmt.Errorf("refresh keys: %w", err)
+			}
+
+			return nil
+		}},
+	}
+}
+
 func (m *Middleware) Authorize(c *gin.Context) {
+	ctx, span := tracing.Start(c.Request.Context(), "auth.Authorize")
+	defer span.End()
+
 	tokenString := m.ExtractToken(c.Request)
-	claims, err := m.Validate(tokenString)
+	_, validateSpan := tracing.Start(ctx, "auth.Validate")
+	claims, err := m.Validate(ctx, tokenString)
+	tracing.End(validateSpan, err)
 	if err != nil {
-		log.Println("Authorize Validate err: ", err)
+		logging.FromContext(ctx).Info("Authorize Validate", "err", err)
 		c.AbortWithStatusJSON(http.StatusUnauthorized, model.ErrUnauthorized)
 
 		return
 	}
 
 	if claims == nil {
-		log.Println("Authorize err: empty claims")
+		logging.FromContext(ctx).Info("Authorize err: empty claims")
 		c.AbortWithStatusJSON(http.StatusUnauthorized, model.ErrUnauthorized)
 
 		return
 	}
 
-	_, err = m.mongo.UsersRepository.Find(claims.BaseClaims.ID)
+	user, err := m.store.Users().Find(ctx, claims.BaseClaims.ID)
 	if err != nil {
-		log.Println("Authorize Find err: ", err)
+		logging.FromContext(ctx).Info("Authorize Find", "err", err)
+		c.AbortWithStatusJSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return
+	}
+	if user.Locked {
+		logging.FromContext(ctx).Info("Authorize user locked", "user_id", user.ID)
+		c.AbortWithStatusJSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return
+	}
+	// A role change takes effect right away, not when the token expires.
+	claims.Role = user.EffectiveRole()
+
+	// The access token shares its id with the refresh token it was issued
+	// with, so a revoked token family locks out its access tokens as well.
+	record, err := m.store.Tokens().Find(ctx, claims.Id)
+	if err != nil || record.Revoked {
+		logging.FromContext(ctx).Info("Authorize revoked token family", "family_id", claims.FamilyID)
 		c.AbortWithStatusJSON(http.StatusUnauthorized, model.ErrUnauthorized)
 
 		return
 	}
+
+	c.Set(ClaimsKey, claims)
+	c.Set(UserKey, user)
+}
+
+// RequireRole authorizes the request and rejects callers whose role is not
+// one of roles.
+func (m *Middleware) RequireRole(roles ...model.Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := ClaimsFromContext(c)
+		if !ok {
+			m.Authorize(c)
+			if c.IsAborted() {
+				return
+			}
+			claims, _ = ClaimsFromContext(c)
+		}
+
+		if !claims.Role.Is(roles...) {
+			logging.FromContext(c.Request.Context()).Warn("RequireRole role not allowed", "role", claims.Role)
+			c.AbortWithStatusJSON(http.StatusForbidden, model.ErrForbidden)
+
+			return
+		}
+
+		c.Next()
+	}
 }
 
-func (m *Middleware) CreateTokens(id uint64) (*Tokens, error) {
-	accessClaims, refreshClaims := GenerateClaims(id)
+// ClaimsFromContext returns the access claims stored by Authorize.
+func ClaimsFromContext(c *gin.Context) (*AccessClaims, bool) {
+	value, ok := c.Get(ClaimsKey)
+	if !ok {
+		return nil, false
+	}
+	claims, ok := value.(*AccessClaims)
+
+	return claims, ok
+}
 
-	at := jwt.NewWithClaims(jwt.SigningMethodES256, accessClaims)
-	accessToken, err := at.SignedString(m.atKey)
+// UserFromContext returns the caller's user record loaded by Authorize.
+func UserFromContext(c *gin.Context) (model.User, bool) {
+	value, ok := c.Get(UserKey)
+	if !ok {
+		return model.User{}, false
+	}
+	user, ok := value.(model.User)
+
+	return user, ok
+}
+
+// StartSession issues a token pair for a new token family and records the
+// refresh token and the session so they can be rotated, listed and revoked.
+func (m *Middleware) StartSession(ctx context.Context, user model.User, info SessionInfo) (*Tokens, error) {
+	ctx, span := tracing.Start(ctx, "auth.StartSession")
+	tokens, err := m.startSession(ctx, user, info)
+	tracing.End(span, err)
+
+	return tokens, err
+}
+
+func (m *Middleware) startSession(ctx context.Context, user model.User, info SessionInfo) (*Tokens, error) {
+	familyID := uuid.NewV4().String()
+	now := time.Now()
+	err := m.store.Sessions().Insert(ctx, model.Session{
+		ID:            familyID,
+		UserID:        user.ID,
+		UserAgent:     info.UserAgent,
+		IP:            info.IP,
+		CreatedAt:     now,
+		LastRefreshAt: now,
+		ExpiresAt:     now.Add(m.refreshTTL),
+	})
 	if err != nil {
-		log.Println("CreateTokens SignedString atKey err: ", err)
+		logging.FromContext(ctx).Error("StartSession Insert", "err", err)
+
+		return nil, err
+	}
+
+	return m.issueTokens(ctx, user.ID, user.EffectiveRole(), familyID)
+}
 
+func (m *Middleware) issueTokens(ctx context.Context, id uint64, role model.Role, familyID string) (*Tokens, error) {
+	tokens, refreshClaims, err := m.createTokens(ctx, id, role, familyID)
+	if err != nil {
 		return nil, err
 	}
 
-	rt := jwt.NewWithClaims(jwt.SigningMethodES256, refreshClaims)
-	refreshToken, err := rt.SignedString(m.rtKey)
+	err = m.store.Tokens().Insert(ctx, model.RefreshToken{
+		ID:        refreshClaims.RefreshUUID,
+		UserID:    id,
+		FamilyID:  familyID,
+		IssuedAt:  time.Unix(refreshClaims.IssuedAt, 0),
+		ExpiresAt: time.Unix(refreshClaims.ExpiresAt, 0),
+	})
 	if err != nil {
-		log.Println("CreateTokens SignedString rtKey err: ", err)
+		logging.FromContext(ctx).Error("issueTokens Insert", "err", err)
 
 		return nil, err
 	}
 
+	return tokens, nil
+}
+
+func (m *Middleware) createTokens(ctx context.Context, id uint64, role model.Role, familyID string) (*Tokens, *RefreshClaims, error) {
+	accessClaims, refreshClaims := m.GenerateClaims(id)
+	accessClaims.Role = role
+	refreshClaims.Role = role
+	accessClaims.FamilyID = familyID
+	refreshClaims.FamilyID = familyID
+
+	accessToken, err := sign(m.atKeys, accessClaims)
+	if err != nil {
+		logging.FromContext(ctx).Error("createTokens sign atKeys", "err", err)
+
+		return nil, nil, err
+	}
+
+	refreshToken, err := sign(m.rtKeys, refreshClaims)
+	if err != nil {
+		logging.FromContext(ctx).Error("createTokens sign rtKeys", "err", err)
+
+		return nil, nil, err
+	}
+
 	return &Tokens{
 		Access:  accessToken,
 		Refresh: refreshToken,
-	}, nil
+	}, refreshClaims, nil
 }
 
-func (m *Middleware) Refresh(refreshToken string) (string, error) {
-	token, err := jwt.ParseWithClaims(refreshToken, &RefreshClaims{},
-		func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodECDSA); !ok {
-				log.Printf("Refresh unexpected signing method: %v", token.Header["alg"])
+// Refresh exchanges a refresh token for a new token pair of the same family.
+// Each refresh token can be used once; presenting a used one again means it
+// leaked, so the whole family is revoked. The id of the token's user is
+// returned as well, also on failure once the token is known, 0 otherwise.
+func (m *Middleware) Refresh(ctx context.Context, refreshToken string) (*Tokens, uint64, error) {
+	ctx, span := tracing.Start(ctx, "auth.Refresh")
+	tokens, userID, err := m.refresh(ctx, refreshToken)
+	tracing.End(span, err)
 
-				return nil, model.ErrUnauthorized
-			}
+	return tokens, userID, err
+}
 
-			return &m.rtKey.PublicKey, nil
-		})
+func (m *Middleware) refresh(ctx context.Context, refreshToken string) (*Tokens, uint64, error) {
+	token, err := jwt.ParseWithClaims(refreshToken, &RefreshClaims{}, m.rtKeys.verificationKey(ctx))
 	if err != nil {
-		log.Println("Refresh ParseWithClaims err: ", err)
+		logging.FromContext(ctx).Info("Refresh ParseWithClaims", "err", err)
 
-		return "", model.ErrUnauthorized
+		return nil, 0, model.ErrUnauthorized
 	}
 
 	claims, ok := token.Claims.(*RefreshClaims)
 	if !ok {
-		log.Printf("Refresh invalid token claims: %v", token.Claims)
+		logging.FromContext(ctx).Info("Refresh invalid token claims")
 
-		return "", model.ErrUnauthorized
+		return nil, 0, model.ErrUnauthorized
 	}
 
 	if !token.Valid {
-		log.Println("Refresh not valid token err")
+		logging.FromContext(ctx).Info("Refresh not valid token err")
+
+		return nil, 0, model.ErrUnauthorized
+	}
+
+	record, err := m.store.Tokens().Use(ctx, claims.RefreshUUID)
+	if err == store.ErrTokenReused {
+		logging.FromContext(ctx).Warn("Refresh token reuse detected, revoking family", "family_id", record.FamilyID)
+		if err := m.revokeFamily(ctx, record.FamilyID); err != nil {
+			return nil, record.UserID, model.ErrInternalServerError
+		}
+
+		return nil, record.UserID, model.ErrUnauthorized
+	}
+	if err != nil {
+		logging.FromContext(ctx).Info("Refresh Use", "err", err)
+
+		return nil, 0, model.ErrUnauthorized
+	}
+
+	user, err := m.store.Users().Find(ctx, record.UserID)
+	if err != nil {
+		logging.FromContext(ctx).Info("Refresh Find", "err", err)
+
+		return nil, record.UserID, model.ErrUnauthorized
+	}
+
+	if user.Locked || user.PasswordResetRequired {
+		logging.FromContext(ctx).Info("Refresh user locked or password reset required", "user_id", user.ID)
+
+		return nil, user.ID, model.ErrUnauthorized
+	}
 
-		return "", model.ErrUnauthorized
+	now := time.Now()
+	err = m.store.Sessions().Touch(ctx, record.FamilyID, now, now.Add(m.refreshTTL))
+	if err != nil {
+		logging.FromContext(ctx).Error("Refresh Touch", "err", err)
 	}
 
-	_, err = m.mongo.UsersRepository.Find(claims.BaseClaims.ID)
+	tokens, err := m.issueTokens(ctx, record.UserID, user.EffectiveRole(), record.FamilyID)
+
+	return tokens, record.UserID, err
+}
+
+// Logout revokes the session the access token belongs to.
+func (m *Middleware) Logout(ctx context.Context, claims *AccessClaims) error {
+	record, err := m.store.Tokens().Find(ctx, claims.Id)
 	if err != nil {
-		log.Println("Refresh Find", err)
+		logging.FromContext(ctx).Info("Logout Find", "err", err)
+
+		return model.ErrUnauthorized
+	}
+
+	return m.revokeFamily(ctx, record.FamilyID)
+}
+
+// LogoutAll revokes every session of the user.
+func (m *Middleware) LogoutAll(ctx context.Context, claims *AccessClaims) error {
+	return m.RevokeUserSessions(ctx, claims.BaseClaims.ID)
+}
+
+func (m *Middleware) RevokeUserSessions(ctx context.Context, userID uint64) error {
+	if err := m.store.Sessions().RevokeUser(ctx, userID); err != nil {
+		return err
+	}
+
+	return m.store.Tokens().RevokeUser(ctx, userID)
+}
+
+// RevokeSession revokes one of the user's sessions.
+func (m *Middleware) RevokeSession(ctx context.Context, userID uint64, sessionID string) error {
+	session, err := m.store.Sessions().Find(ctx, sessionID)
+	if err != nil || session.UserID != userID {
+		logging.FromContext(ctx).Info("RevokeSession Find", "err", err)
 
-		return "", model.ErrInternalServerError
+		return model.ErrNotFound
+	}
+
+	return m.revokeFamily(ctx, sessionID)
+}
 
+func (m *Middleware) revokeFamily(ctx context.Context, familyID string) error {
+	if err := m.store.Sessions().Revoke(ctx, familyID); err != nil {
+		return err
 	}
 
-	return m.GenerateAccessToken(claims.ID)
+	return m.store.Tokens().RevokeFamily(ctx, familyID)
 }
 
-func (m *Middleware) GenerateAccessToken(id uint64) (string, error) {
-	accessClaims, _ := GenerateClaims(id)
+func (m *Middleware) GenerateAccessToken(ctx context.Context, id uint64) (string, error) {
+	accessClaims, _ := m.GenerateClaims(id)
 
-	at := jwt.NewWithClaims(jwt.SigningMethodES256, accessClaims)
-	accessToken, err := at.SignedString(m.atKey)
+	accessToken, err := sign(m.atKeys, accessClaims)
 	if err != nil {
-		log.Println("GenerateAccessToken SignedString err: ", err)
+		logging.FromContext(ctx).Error("GenerateAccessToken sign", "err", err)
 
 		return "", err
 	}
@@ -174,13 +420,13 @@ func (m *Middleware) GenerateAccessToken(id uint64) (string, error) {
 	return accessToken, nil
 }
 
-func GenerateClaims(id uint64) (*AccessClaims, *RefreshClaims) {
+func (m *Middleware) GenerateClaims(id uint64) (*AccessClaims, *RefreshClaims) {
 	access := AccessClaims{
-		BaseClaims: NewClaims(id, AccessTokenTTL),
+		BaseClaims: NewClaims(id, m.accessTTL),
 	}
 
 	refresh := RefreshClaims{
-		BaseClaims: NewClaims(id, RefreshTokenTTL),
+		BaseClaims: NewClaims(id, m.refreshTTL),
 		UserID:     id,
 	}
 
@@ -202,39 +448,31 @@ func NewClaims(id uint64, ttl time.Duration) BaseClaims {
 	}
 }
 
-func (m *Middleware) RefreshTokenKey() *ecdsa.PrivateKey {
-	return m.rtKey
+func (m *Middleware) AccessKeys() *KeySet {
+	return m.atKeys
 }
 
 func (m *Middleware) ExtractToken(r *http.Request) string {
 	return r.Header.Get("Authorization")
 }
 
-func (m *Middleware) Validate(raw string) (*AccessClaims, error) {
-	token, err := jwt.ParseWithClaims(raw, &AccessClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodECDSA); !ok {
-			log.Printf("Validate unexpected signing method: %v", token.Header["alg"])
-
-			return nil, model.ErrUnauthorized
-		}
-
-		return &m.atKey.PublicKey, nil
-	})
+func (m *Middleware) Validate(ctx context.Context, raw string) (*AccessClaims, error) {
+	token, err := jwt.ParseWithClaims(raw, &AccessClaims{}, m.atKeys.verificationKey(ctx))
 	if err != nil {
-		log.Println("Validate ParseWithClaims err: ", err)
+		logging.FromContext(ctx).Info("Validate ParseWithClaims", "err", err)
 
 		return nil, model.ErrUnauthorized
 	}
 
 	claims, ok := token.Claims.(*AccessClaims)
 	if !ok {
-		log.Printf("Validate invalid token claims: %v", token.Claims)
+		logging.FromContext(ctx).Info("Validate invalid token claims")
 
 		return nil, model.ErrUnauthorized
 	}
 
 	if !token.Valid {
-		log.Println("Validate not valid token err")
+		logging.FromContext(ctx).Info("Validate not valid token err")
 
 		return nil, model.ErrUnauthorized
 	}
@@ -242,13 +480,44 @@ func (m *Middleware) Validate(raw string) (*AccessClaims, error) {
 	return claims, nil
 }
 
-func GenerateECDSAPrivateKey() (*ecdsa.PrivateKey, error) {
-	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+func sign(keys *KeySet, claims jwt.Claims) (string, error) {
+	key, err := keys.Current()
 	if err != nil {
-		log.Fatalf("generateECDSAPrivateKey GenerateKey err: %v", err)
-
-		return nil, err
+		return "", err
 	}
 
-	return privateKey, nil
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+	token.Header["kid"] = key.ID
+
+	return token.SignedString(key.PrivateKey)
+}
+
+// verificationKey returns the jwt.Keyfunc picking the public key named by the
+// kid header, logging to the logger of ctx. Tokens issued before kids were
+// introduced fall back to the current key.
+func (s *KeySet) verificationKey(ctx context.Context) jwt.Keyfunc {
+	return func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodECDSA); !ok {
+			logging.FromContext(ctx).Info("verificationKey unexpected signing method", "alg", token.Header["alg"])
+
+			return nil, model.ErrUnauthorized
+		}
+
+		var (
+			key *SigningKey
+			err error
+		)
+		if kid, ok := token.Header["kid"].(string); ok {
+			key, err = s.Key(kid)
+		} else {
+			key, err = s.Current()
+		}
+		if err != nil {
+			logging.FromContext(ctx).Info("verificationKey", "err", err)
+
+			return nil, model.ErrUnauthorized
+		}
+
+		return &key.PrivateKey.PublicKey, nil
+	}
 }
@@ -1,31 +1,33 @@
 package auth
 
 import (
+	"bookService/model"
+	"context"
 	"crypto/ecdsa"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 )
 
-func TestCreateTokens(t *testing.T) {
-	atKey, _ := GenerateECDSAPrivateKey()
-	rtKey, _ := GenerateECDSAPrivateKey()
-	middleware := NewAuthMiddleware(atKey, rtKey, nil)
+func TestStartSession(t *testing.T) {
+	middleware, user := newTestMiddleware(t)
 
-	tokens, err := middleware.CreateTokens(123)
+	tokens, err := middleware.StartSession(context.Background(), user, SessionInfo{})
 	assert.NoError(t, err)
-	assert.NotNil(t, tokens.Access)
-	assert.NotNil(t, tokens.Refresh)
+	assert.NotEmpty(t, tokens.Access)
+	assert.NotEmpty(t, tokens.Refresh)
+	assert.True(t, authorizes(middleware, tokens.Access))
 }
 
 func TestExtractToken(t *testing.T) {
 	atKey, _ := GenerateECDSAPrivateKey()
 	rtKey, _ := GenerateECDSAPrivateKey()
-	middleware := NewAuthMiddleware(atKey, rtKey, nil)
+	middleware := NewAuthMiddleware(NewKeySet(atKey), NewKeySet(rtKey), nil)
 
 	req, _ := http.NewRequest("GET", "/", nil)
 	req.Header.Set("Authorization", "Bearer fake_token")
@@ -34,15 +36,24 @@ func TestExtractToken(t *testing.T) {
 	assert.Equal(t, "Bearer fake_token", token)
 }
 
+func TestSetTokenTTLs(t *testing.T) {
+	middleware := NewAuthMiddleware(nil, nil, nil)
+	middleware.SetTokenTTLs(5*time.Minute, time.Hour)
+
+	access, refresh := middleware.GenerateClaims(123)
+	assert.InDelta(t, time.Now().Add(5*time.Minute).Unix(), access.ExpiresAt, 2)
+	assert.InDelta(t, time.Now().Add(time.Hour).Unix(), refresh.ExpiresAt, 2)
+}
+
 func TestValidateToken(t *testing.T) {
 	atKey, _ := GenerateECDSAPrivateKey()
 	rtKey, _ := GenerateECDSAPrivateKey()
-	middleware := NewAuthMiddleware(atKey, rtKey, nil)
+	middleware := NewAuthMiddleware(NewKeySet(atKey), NewKeySet(rtKey), nil)
 
-	accessClaims, _ := GenerateClaims(123)
+	accessClaims, _ := middleware.GenerateClaims(123)
 	accessToken, _ := GenerateToken(atKey, accessClaims)
 
-	claims, err := middleware.Validate(accessToken)
+	claims, err := middleware.Validate(context.Background(), accessToken)
 	assert.NoError(t, err)
 	assert.NotNil(t, claims)
 }
@@ -79,3 +90,26 @@ func GenerateToken(key *ecdsa.PrivateKey, claims jwt.Claims) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
 	return token.SignedString(key)
 }
+
+func TestRequireRole(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	middleware := NewAuthMiddleware(nil, nil, nil)
+
+	for role, want := range map[model.Role]int{
+		model.RoleReader: http.StatusForbidden,
+		model.RoleAdmin:  http.StatusOK,
+	} {
+		r := gin.New()
+		r.Use(func(c *gin.Context) {
+			c.Set(ClaimsKey, &AccessClaims{BaseClaims: BaseClaims{ID: 123, Role: role}})
+		})
+		r.GET("/", middleware.RequireRole(model.RoleLibrarian, model.RoleAdmin), func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"message": "authorized"})
+		})
+
+		req, _ := http.NewRequest("GET", "/", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, want, w.Code, role)
+	}
+}
@@ -0,0 +1,440 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	keyFileExt  = ".pem"
+	keyFileMode = 0o600
+
+	// KeyActivationDelay is how long a freshly rotated key is published in
+	// the JWKS before it is used for signing, so that verifiers caching the
+	// JWKS pick it up before the first token signed with it arrives.
+	KeyActivationDelay = time.Minute * 5
+	keyCheckInterval   = time.Minute
+)
+
+var (
+	ErrNoSigningKey = errors.New("no active signing key")
+	ErrUnknownKey   = errors.New("unknown signing key")
+)
+
+type SigningKey struct {
+	ID          string
+	PrivateKey  *ecdsa.PrivateKey
+	ActivatesAt time.Time
+}
+
+// KeySet holds the ECDSA keys used to sign one kind of token. The newest
+// active key signs new tokens, every key still in the set verifies them.
+type KeySet struct {
+	mu        sync.RWMutex
+	keys      []*SigningKey
+	dir       string
+	retention time.Duration
+}
+
+type JWK struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+func NewKeySet(keys ...*ecdsa.PrivateKey) *KeySet {
+	set := &KeySet{}
+	for _, key := range keys {
+		set.add(&SigningKey{
+			ID:          Thumbprint(&key.PublicKey),
+			PrivateKey:  key,
+			ActivatesAt: time.Now(),
+		})
+	}
+
+	return set
+}
+
+// LoadKeySet loads the keys from dir if it is set, otherwise from the single
+// PEM file. Without either an ephemeral key is generated, which means tokens
+// do not survive a restart.
+func LoadKeySet(file, dir string, retention time.Duration) (*KeySet, error) {
+	switch {
+	case dir != "":
+		return LoadKeyDir(dir, retention)
+	case file != "":
+		return LoadKeyFile(file)
+	}
+
+	slog.Warn("LoadKeySet no key file or directory configured, generating ephemeral key")
+	key, err := GenerateECDSAPrivateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewKeySet(key), nil
+}
+
+func LoadKeyFile(path string) (*KeySet, error) {
+	key, err := readKeyFile(path)
+	if err != nil {
+		slog.Error("LoadKeyFile readKeyFile", "err", err)
+
+		return nil, err
+	}
+
+	return NewKeySet(key), nil
+}
+
+// LoadKeyDir loads every *.pem key from dir. A key becomes active at the
+// modification time of its file. If the directory holds no keys a first one
+// is generated and written there.
+func LoadKeyDir(dir string, retention time.Duration) (*KeySet, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		slog.Error("LoadKeyDir MkdirAll", "err", err)
+
+		return nil, err
+	}
+
+	set := &KeySet{
+		dir:       dir,
+		retention: retention,
+	}
+	if err := set.Reload(); err != nil {
+		return nil, err
+	}
+
+	if len(set.Keys()) == 0 {
+		if _, err := set.Rotate(time.Now()); err != nil {
+			return nil, err
+		}
+	}
+
+	return set, nil
+}
+
+// Reload re-reads the key directory, picking up keys rotated by other
+// replicas sharing it.
+func (s *KeySet) Reload() error {
+	if s.dir == "" {
+		return nil
+	}
+
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		slog.Error("Reload ReadDir", "err", err)
+
+		return err
+	}
+
+	keys := make([]*SigningKey, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != keyFileExt {
+			continue
+		}
+
+		path := filepath.Join(s.dir, entry.Name())
+		info, err := entry.Info()
+		if err != nil {
+			slog.Warn("Reload Info", "err", err)
+
+			continue
+		}
+
+		key, err := readKeyFile(path)
+		if err != nil {
+			slog.Warn("Reload readKeyFile", "path", path, "err", err)
+
+			continue
+		}
+
+		keys = append(keys, &SigningKey{
+			ID:          Thumbprint(&key.PublicKey),
+			PrivateKey:  key,
+			ActivatesAt: info.ModTime(),
+		})
+	}
+
+	sortKeys(keys)
+
+	s.mu.Lock()
+	s.keys = keys
+	s.mu.Unlock()
+
+	return nil
+}
+
+// Rotate generates a new key that starts signing at activatesAt.
+func (s *KeySet) Rotate(activatesAt time.Time) (*SigningKey, error) {
+	privateKey, err := GenerateECDSAPrivateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	key := &SigningKey{
+		ID:          Thumbprint(&privateKey.PublicKey),
+		PrivateKey:  privateKey,
+		ActivatesAt: activatesAt,
+	}
+
+	if s.dir != "" {
+		if err := writeKeyFile(filepath.Join(s.dir, key.ID+keyFileExt), key); err != nil {
+			slog.Error("Rotate writeKeyFile", "err", err)
+
+			return nil, err
+		}
+	}
+
+	s.add(key)
+
+	return key, nil
+}
+
+// StartRotation rotates the keys every interval until ctx is done. The next
+// key is generated KeyActivationDelay before it takes over signing, and keys
+// retired for longer than the retention period are removed.
+func (s *KeySet) StartRotation(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(keyCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		s.rotateIfDue(time.Now(), interval)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *KeySet) rotateIfDue(now time.Time, interval time.Duration) {
+	if err := s.Reload(); err != nil {
+		return
+	}
+
+	keys := s.Keys()
+	if len(keys) == 0 {
+		return
+	}
+
+	newest := keys[len(keys)-1]
+	next := newest.ActivatesAt.Add(interval)
+	if !now.Add(KeyActivationDelay).Before(next) {
+		if next.Before(now) {
+			next = now.Add(KeyActivationDelay)
+		}
+		if _, err := s.Rotate(next); err != nil {
+			return
+		}
+	}
+
+	s.prune(now)
+}
+
+// prune drops keys that were superseded longer ago than the retention
+// period, since no unexpired token can have been signed with them.
+func (s *KeySet) prune(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	kept := s.keys[:0]
+	for i, key := range s.keys {
+		if i+1 < len(s.keys) {
+			retiredAt := s.keys[i+1].ActivatesAt
+			if retiredAt.Before(now) && now.Sub(retiredAt) > s.retention {
+				if s.dir != "" {
+					err := os.Remove(filepath.Join(s.dir, key.ID+keyFileExt))
+					if err != nil && !os.IsNotExist(err) {
+						slog.Warn("prune Remove", "err", err)
+					}
+				}
+
+				continue
+			}
+		}
+		kept = append(kept, key)
+	}
+	s.keys = kept
+}
+
+// Current returns the newest key that is already active.
+func (s *KeySet) Current() (*SigningKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := time.Now()
+	for i := len(s.keys) - 1; i >= 0; i-- {
+		if !s.keys[i].ActivatesAt.After(now) {
+			return s.keys[i], nil
+		}
+	}
+
+	return nil, ErrNoSigningKey
+}
+
+func (s *KeySet) Key(kid string) (*SigningKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, key := range s.keys {
+		if key.ID == kid {
+			return key, nil
+		}
+	}
+
+	return nil, ErrUnknownKey
+}
+
+func (s *KeySet) Keys() []*SigningKey {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys := make([]*SigningKey, len(s.keys))
+	copy(keys, s.keys)
+
+	return keys
+}
+
+// JWKS returns the public part of every key in the set, including keys not
+// active yet, so verifiers learn about them ahead of time.
+func (s *KeySet) JWKS() JWKS {
+	keys := s.Keys()
+	jwks := JWKS{Keys: make([]JWK, 0, len(keys))}
+	for _, key := range keys {
+		jwk := NewJWK(&key.PrivateKey.PublicKey)
+		jwk.Kid = key.ID
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+
+	return jwks
+}
+
+func (s *KeySet) add(key *SigningKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, existing := range s.keys {
+		if existing.ID == key.ID {
+			return
+		}
+	}
+	s.keys = append(s.keys, key)
+	sortKeys(s.keys)
+}
+
+func NewJWK(key *ecdsa.PublicKey) JWK {
+	size := (key.Curve.Params().BitSize + 7) / 8
+
+	return JWK{
+		Kty: "EC",
+		Crv: key.Curve.Params().Name,
+		X:   base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, size))),
+		Y:   base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, size))),
+		Use: "sig",
+		Alg: "ES256",
+	}
+}
+
+// Thumbprint returns the RFC 7638 thumbprint of the key, used as its kid so
+// that every replica derives the same id for the same key.
+func Thumbprint(key *ecdsa.PublicKey) string {
+	jwk := NewJWK(key)
+	members, err := json.Marshal(struct {
+		Crv string `json:"crv"`
+		Kty string `json:"kty"`
+		X   string `json:"x"`
+		Y   string `json:"y"`
+	}{jwk.Crv, jwk.Kty, jwk.X, jwk.Y})
+	if err != nil {
+		slog.Error("Thumbprint Marshal", "err", err)
+	}
+	sum := sha256.Sum256(members)
+
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func GenerateECDSAPrivateKey() (*ecdsa.PrivateKey, error) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		slog.Error("GenerateECDSAPrivateKey GenerateKey", "err", err)
+
+		return nil, err
+	}
+
+	return privateKey, nil
+}
+
+func readKeyFile(path string) (*ecdsa.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM block found", path)
+	}
+
+	switch strings.ToUpper(block.Type) {
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		ecKey, ok := key.(*ecdsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("%s: not an ECDSA key", path)
+		}
+
+		return ecKey, nil
+	}
+
+	return nil, fmt.Errorf("%s: unsupported PEM block %q", path, block.Type)
+}
+
+func writeKeyFile(path string, key *SigningKey) error {
+	der, err := x509.MarshalECPrivateKey(key.PrivateKey)
+	if err != nil {
+		return err
+	}
+
+	data := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+	if err := os.WriteFile(path, data, keyFileMode); err != nil {
+		return err
+	}
+
+	return os.Chtimes(path, key.ActivatesAt, key.ActivatesAt)
+}
+
+func sortKeys(keys []*SigningKey) {
+	sort.SliceStable(keys, func(i, j int) bool {
+		return keys[i].ActivatesAt.Before(keys[j].ActivatesAt)
+	})
+}
@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadKeyDirPersistsKeys(t *testing.T) {
+	dir := t.TempDir()
+
+	first, err := LoadKeyDir(dir, RefreshTokenTTL)
+	assert.NoError(t, err)
+	current, err := first.Current()
+	assert.NoError(t, err)
+
+	second, err := LoadKeyDir(dir, RefreshTokenTTL)
+	assert.NoError(t, err)
+	reloaded, err := second.Current()
+	assert.NoError(t, err)
+	assert.Equal(t, current.ID, reloaded.ID)
+}
+
+func TestRotatedKeysStillVerify(t *testing.T) {
+	dir := t.TempDir()
+	atKeys, err := LoadKeyDir(dir, AccessTokenTTL)
+	assert.NoError(t, err)
+	rtKeys, _ := LoadKeyDir(t.TempDir(), RefreshTokenTTL)
+	middleware, user := newTestMiddleware(t)
+	middleware.atKeys, middleware.rtKeys = atKeys, rtKeys
+
+	tokens, err := middleware.StartSession(context.Background(), user, SessionInfo{})
+	assert.NoError(t, err)
+
+	_, err = atKeys.Rotate(time.Now().Add(-time.Second))
+	assert.NoError(t, err)
+	assert.Len(t, atKeys.JWKS().Keys, 2)
+
+	claims, err := middleware.Validate(context.Background(), tokens.Access)
+	assert.NoError(t, err)
+	assert.Equal(t, user.ID, claims.ID)
+	assert.True(t, authorizes(middleware, tokens.Access))
+}
+
+func TestScheduledKeyIsPublishedBeforeSigning(t *testing.T) {
+	atKeys, err := LoadKeyDir(t.TempDir(), AccessTokenTTL)
+	assert.NoError(t, err)
+	current, _ := atKeys.Current()
+
+	atKeys.rotateIfDue(current.ActivatesAt.Add(time.Hour), time.Hour)
+
+	assert.Len(t, atKeys.JWKS().Keys, 2)
+	stillCurrent, err := atKeys.Current()
+	assert.NoError(t, err)
+	assert.Equal(t, current.ID, stillCurrent.ID)
+}
@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"bookService/model"
+	"bookService/store"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestMiddleware signs with fresh keys and keeps tokens in memory, with a
+// single user signed up.
+func newTestMiddleware(t *testing.T) (*Middleware, model.User) {
+	t.Helper()
+
+	atKey, _ := GenerateECDSAPrivateKey()
+	rtKey, _ := GenerateECDSAPrivateKey()
+	memory := store.NewMemoryStore()
+	user, err := memory.Users().Insert(context.Background(), model.User{Login: "reader@example.com"})
+	assert.NoError(t, err)
+
+	return NewAuthMiddleware(NewKeySet(atKey), NewKeySet(rtKey), memory), user
+}
+
+// authorizes reports whether the access token passes Authorize.
+func authorizes(m *Middleware, accessToken string) bool {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/", m.Authorize, func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Authorization", accessToken)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	return w.Code == http.StatusOK
+}
+
+func TestRefreshRotatesTokens(t *testing.T) {
+	middleware, user := newTestMiddleware(t)
+	ctx := context.Background()
+
+	first, err := middleware.StartSession(ctx, user, SessionInfo{})
+	assert.NoError(t, err)
+	second, userID, err := middleware.Refresh(ctx, first.Refresh)
+	assert.NoError(t, err)
+	assert.Equal(t, user.ID, userID)
+	assert.NotEqual(t, first.Access, second.Access)
+	assert.NotEqual(t, first.Refresh, second.Refresh)
+	assert.True(t, authorizes(middleware, second.Access))
+
+	third, _, err := middleware.Refresh(ctx, second.Refresh)
+	assert.NoError(t, err)
+	assert.True(t, authorizes(middleware, third.Access))
+}
+
+func TestRefreshReuseRevokesFamily(t *testing.T) {
+	middleware, user := newTestMiddleware(t)
+	ctx := context.Background()
+
+	first, err := middleware.StartSession(ctx, user, SessionInfo{})
+	assert.NoError(t, err)
+	second, _, err := middleware.Refresh(ctx, first.Refresh)
+	assert.NoError(t, err)
+
+	// The old refresh token was stolen and replayed.
+	_, userID, err := middleware.Refresh(ctx, first.Refresh)
+	assert.Equal(t, model.ErrUnauthorized, err)
+	assert.Equal(t, user.ID, userID)
+
+	_, _, err = middleware.Refresh(ctx, second.Refresh)
+	assert.Equal(t, model.ErrUnauthorized, err)
+	assert.False(t, authorizes(middleware, second.Access))
+	assert.False(t, authorizes(middleware, first.Access))
+
+	// Other sessions of the user are not affected.
+	other, err := middleware.StartSession(ctx, user, SessionInfo{})
+	assert.NoError(t, err)
+	assert.True(t, authorizes(middleware, other.Access))
+}
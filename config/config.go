@@ -1,23 +1,260 @@
 package config
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
 
-func New() (*Config, error) {
-	config, err := NewFromEnv()
-	if err != nil {
-		return nil, err
+// Validate reports every setting that cannot work, so that a misconfigured
+// instance fails at startup instead of on the first request.
+func (c *Config) Validate() error {
+	var errs []error
+	if err := c.Server.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := c.Store.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if c.Store.Backend == StoreBackendMongo {
+		if err := c.Mongo.Validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, section := range []interface{ Validate() error }{&c.Auth, &c.Mail, &c.CORS, &c.Log, &c.Trace} {
+		if err := section.Validate(); err != nil {
+			errs = append(errs, err)
+		}
 	}
 
-	return config, nil
+	return errors.Join(errs...)
 }
 
-func (c *MongoConfig) DSN() string {
-	var dsn string
-	if c.Username != "" && c.Password != "" {
-		dsn = fmt.Sprintf("mongodb://%s:%s@%s:%d", c.Username, c.Password, c.Host, c.Port)
+func (c *ServerConfig) Validate() error {
+	var errs []error
+	if _, port, err := net.SplitHostPort(c.Addr); err != nil {
+		errs = append(errs, fmt.Errorf("SERVER_ADDR: %w", err))
+	} else if n, err := strconv.Atoi(port); err != nil || n < 0 || n > 65535 {
+		errs = append(errs, fmt.Errorf("SERVER_ADDR: %q is not a valid port", port))
+	}
+	for name, timeout := range map[string]time.Duration{
+		"SERVER_READ_HEADER_TIMEOUT": c.ReadHeaderTimeout,
+		"SERVER_READ_TIMEOUT":        c.ReadTimeout,
+		"SERVER_WRITE_TIMEOUT":       c.WriteTimeout,
+		"SERVER_IDLE_TIMEOUT":        c.IdleTimeout,
+		"SERVER_DRAIN_DELAY":         c.DrainDelay,
+	} {
+		if timeout < 0 {
+			errs = append(errs, fmt.Errorf("%s must not be negative", name))
+		}
+	}
+	if c.ShutdownTimeout <= 0 {
+		errs = append(errs, errors.New("SERVER_SHUTDOWN_TIMEOUT must be positive"))
+	} else if c.DrainDelay >= c.ShutdownTimeout {
+		errs = append(errs, errors.New("SERVER_DRAIN_DELAY must be shorter than SERVER_SHUTDOWN_TIMEOUT"))
+	}
+
+	return joinSorted(errs)
+}
+
+func (c *StoreConfig) Validate() error {
+	var errs []error
+	if c.Backend != StoreBackendMongo && c.Backend != StoreBackendMemory {
+		errs = append(errs, fmt.Errorf("STORE_BACKEND: unknown backend %q, use %s or %s",
+			c.Backend, StoreBackendMongo, StoreBackendMemory))
+	}
+	if c.NodeID < -1 || c.NodeID > 1023 {
+		errs = append(errs, fmt.Errorf("STORE_NODE_ID: %d is not between 0 and 1023", c.NodeID))
+	}
+	if c.TrashRetention > 0 && c.TrashPurgeInterval <= 0 {
+		errs = append(errs, errors.New("STORE_TRASH_PURGE_INTERVAL must be positive"))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (c *MongoConfig) Validate() error {
+	var errs []error
+	if c.URI != "" {
+		// url.Parse rejects the comma separated host lists of replica sets,
+		// so the URI is only checked as far as needed here. The driver
+		// validates the rest when connecting.
+		scheme, rest, ok := strings.Cut(c.URI, "://")
+		if !ok || (scheme != "mongodb" && scheme != "mongodb+srv") {
+			errs = append(errs, errors.New("MONGO_URI: must be a mongodb:// or mongodb+srv:// connection string"))
+		}
+		authority, _, _ := strings.Cut(rest, "/")
+		if strings.Contains(authority, "@") && c.Username != "" {
+			errs = append(errs, errors.New("MONGO_URI: holds credentials, do not set MONGO_USERNAME as well"))
+		}
 	} else {
-		dsn = fmt.Sprintf("mongodb://%s:%d", c.Host, c.Port)
+		if c.Host == "" {
+			errs = append(errs, errors.New("MONGO_HOST or MONGO_URI is required"))
+		}
+		if c.Port < 1 || c.Port > 65535 {
+			errs = append(errs, fmt.Errorf("MONGO_PORT: %d is not a valid port", c.Port))
+		}
+	}
+
+	if c.Database == "" {
+		errs = append(errs, errors.New("MONGO_DATABASE is required"))
+	}
+	if (c.Username == "") != (c.Password == "") {
+		errs = append(errs, errors.New("MONGO_USERNAME and MONGO_PWD must be set together"))
+	}
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		errs = append(errs, errors.New("MONGO_TLS_CERT_FILE and MONGO_TLS_KEY_FILE must be set together"))
+	}
+
+	for name, path := range map[string]string{
+		"MONGO_TLS_CA_FILE":   c.TLSCAFile,
+		"MONGO_TLS_CERT_FILE": c.TLSCertFile,
+		"MONGO_TLS_KEY_FILE":  c.TLSKeyFile,
+	} {
+		if path == "" {
+			continue
+		}
+		if _, err := os.Stat(path); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+
+	return joinSorted(errs)
+}
+
+func (c *AuthConfig) Validate() error {
+	var errs []error
+	if c.KeyRotationInterval < 0 {
+		errs = append(errs, errors.New("AUTH_KEY_ROTATION_INTERVAL must not be negative"))
+	}
+	// Rotated keys are only shared through the key directories, without them
+	// every replica would rotate to keys the others do not know.
+	if c.KeyRotationInterval > 0 && (c.AccessKeysDir == "" || c.RefreshKeysDir == "") {
+		errs = append(errs, errors.New("AUTH_KEY_ROTATION_INTERVAL requires AUTH_ACCESS_KEYS_DIR and AUTH_REFRESH_KEYS_DIR"))
+	}
+	if c.AccessTokenTTL <= 0 {
+		errs = append(errs, errors.New("AUTH_ACCESS_TOKEN_TTL must be positive"))
+	}
+	if c.RefreshTokenTTL < c.AccessTokenTTL {
+		errs = append(errs, errors.New("AUTH_REFRESH_TOKEN_TTL must not be shorter than AUTH_ACCESS_TOKEN_TTL"))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (c *MailConfig) Validate() error {
+	if c.Host == "" {
+		return nil
+	}
+
+	var errs []error
+	if c.Port < 1 || c.Port > 65535 {
+		errs = append(errs, fmt.Errorf("MAIL_PORT: %d is not a valid port", c.Port))
+	}
+	if c.Password != "" && c.Username == "" {
+		errs = append(errs, errors.New("MAIL_USERNAME is required with MAIL_PASSWORD"))
+	}
+	if c.Sender() == "" {
+		errs = append(errs, errors.New("MAIL_FROM or MAIL_USERNAME is required with MAIL_HOST"))
+	}
+	if u, err := url.Parse(c.RecoveryURL); err != nil || !u.IsAbs() {
+		errs = append(errs, fmt.Errorf("MAIL_RECOVERY_URL: %q is not an absolute URL", c.RecoveryURL))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (c *CORSConfig) Validate() error {
+	var errs []error
+	if len(c.AllowedOrigins) == 0 {
+		errs = append(errs, errors.New("CORS_ALLOWED_ORIGINS is required"))
+	}
+	for _, origin := range c.AllowedOrigins {
+		if origin == "*" {
+			if c.AllowCredentials {
+				errs = append(errs, errors.New("CORS_ALLOW_CREDENTIALS cannot be used with CORS_ALLOWED_ORIGINS *, list the origins"))
+			}
+
+			continue
+		}
+		if u, err := url.Parse(origin); err != nil || u.Scheme == "" || u.Host == "" || u.Path != "" {
+			errs = append(errs, fmt.Errorf("CORS_ALLOWED_ORIGINS: %q is not an origin like https://example.com", origin))
+		}
+	}
+	if c.MaxAge < 0 {
+		errs = append(errs, errors.New("CORS_MAX_AGE must not be negative"))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (c *LogConfig) Validate() error {
+	var errs []error
+	if c.Format != "text" && c.Format != "json" {
+		errs = append(errs, fmt.Errorf("LOG_FORMAT: unknown format %q, use text or json", c.Format))
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Level)); err != nil {
+		errs = append(errs, fmt.Errorf("LOG_LEVEL: unknown level %q, use debug, info, warn or error", c.Level))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (c *TraceConfig) Validate() error {
+	var errs []error
+	switch c.Exporter {
+	case TraceExporterNone, TraceExporterOTLP, TraceExporterStdout:
+	case TraceExporterFile:
+		if c.File == "" {
+			errs = append(errs, errors.New("TRACE_FILE is required with TRACE_EXPORTER=file"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("TRACE_EXPORTER: unknown exporter %q, use %s, %s, %s or %s", c.Exporter,
+			TraceExporterNone, TraceExporterOTLP, TraceExporterStdout, TraceExporterFile))
 	}
+	if c.SampleRatio < 0 || c.SampleRatio > 1 {
+		errs = append(errs, fmt.Errorf("TRACE_SAMPLE_RATIO: %g is not between 0 and 1", c.SampleRatio))
+	}
+
+	return errors.Join(errs...)
+}
+
+// joinSorted joins errs in a stable order, for checks that range over maps.
+func joinSorted(errs []error) error {
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Error() < errs[j].Error() })
+
+	return errors.Join(errs...)
+}
+
+// DSN is the connection string to dial. Credentials set through
+// MONGO_USERNAME and MONGO_PWD are escaped into it.
+func (c *MongoConfig) DSN() string {
+	if c.URI != "" {
+		return c.URI
+	}
+
+	dsn := url.URL{
+		Scheme: "mongodb",
+		Host:   net.JoinHostPort(c.Host, strconv.FormatInt(c.Port, 10)),
+		Path:   "/",
+	}
+	if c.Username != "" {
+		dsn.User = url.UserPassword(c.Username, c.Password)
+	}
+
+	return dsn.String()
+}
 
-	return dsn
+// UseTLS reports whether TLS is requested through MONGO_TLS or implied by
+// a certificate setting. TLS options inside MONGO_URI are left to the driver.
+func (c *MongoConfig) UseTLS() bool {
+	return c.TLS || c.TLSCAFile != "" || c.TLSCertFile != ""
 }
@@ -1,24 +1,164 @@
 package config
 
-import "github.com/caarlos0/env/v6"
+import "time"
 
+// Config is the complete configuration. Every setting has an environment
+// variable, its section prefix followed by the name, and the same name in
+// the section of a config file or as a flag, e.g. MONGO_MAX_POOL_SIZE,
+// max_pool_size in [mongo] and -mongo.max_pool_size. Settings tagged secret
+// are redacted when printed.
 type Config struct {
-	Mongo MongoConfig
+	Server ServerConfig
+	Store  StoreConfig
+	Mongo  MongoConfig
+	Auth   AuthConfig
+	Mail   MailConfig
+	CORS   CORSConfig
+	Log    LogConfig
+	Trace  TraceConfig
+}
+
+// ServerConfig is where and how the HTTP server listens. The write timeout
+// leaves room for the audit export, the longest running response.
+type ServerConfig struct {
+	Addr              string        `env:"SERVER_ADDR" envDefault:"0.0.0.0:8080"`
+	ReadHeaderTimeout time.Duration `env:"SERVER_READ_HEADER_TIMEOUT" envDefault:"5s"`
+	ReadTimeout       time.Duration `env:"SERVER_READ_TIMEOUT" envDefault:"30s"`
+	WriteTimeout      time.Duration `env:"SERVER_WRITE_TIMEOUT" envDefault:"2m"`
+	IdleTimeout       time.Duration `env:"SERVER_IDLE_TIMEOUT" envDefault:"2m"`
+	// DrainDelay keeps serving after readiness turned failing on shutdown,
+	// so that load balancers stop routing here before the listener closes.
+	DrainDelay time.Duration `env:"SERVER_DRAIN_DELAY" envDefault:"5s"`
+	// ShutdownTimeout bounds the whole shutdown: the drain delay, in-flight
+	// requests, background work and closing the store.
+	ShutdownTimeout time.Duration `env:"SERVER_SHUTDOWN_TIMEOUT" envDefault:"20s"`
+}
+
+const (
+	StoreBackendMongo  = "mongo"
+	StoreBackendMemory = "memory"
+)
+
+// StoreConfig selects the storage backend. The memory backend needs no
+// database but loses all data on restart.
+type StoreConfig struct {
+	Backend string `env:"STORE_BACKEND" envDefault:"mongo"`
+	// SeedFixtures fills an empty catalog with sample books at startup.
+	SeedFixtures bool `env:"STORE_SEED_FIXTURES"`
+	// NodeID tells replicas apart in generated ids, 0 to 1023. The default
+	// -1 derives it from the host name.
+	NodeID int `env:"STORE_NODE_ID" envDefault:"-1"`
+	// TrashRetention is how long deleted books can be restored before they
+	// are purged, 0 keeps them forever.
+	TrashRetention     time.Duration `env:"STORE_TRASH_RETENTION" envDefault:"720h"`
+	TrashPurgeInterval time.Duration `env:"STORE_TRASH_PURGE_INTERVAL" envDefault:"1h"`
 }
 
 type MongoConfig struct {
+	// URI is a full connection string. It replaces Host, Port and, if it
+	// carries them, the credentials.
+	URI      string `env:"MONGO_URI" secret:"true"`
 	Host     string `env:"MONGO_HOST"`
-	Port     int64  `env:"MONGO_PORT"`
+	Port     int64  `env:"MONGO_PORT" envDefault:"27017"`
 	Database string `env:"MONGO_DATABASE"`
 	Username string `env:"MONGO_USERNAME"`
-	Password string `env:"MONGO_PWD"`
+	Password string `env:"MONGO_PWD" secret:"true"`
+	// AuthSource is the database holding the user, admin by default.
+	AuthSource       string `env:"MONGO_AUTH_SOURCE"`
+	ReplicaSet       string `env:"MONGO_REPLICA_SET"`
+	DirectConnection bool   `env:"MONGO_DIRECT_CONNECTION"`
+
+	TLS bool `env:"MONGO_TLS"`
+	// TLSCAFile verifies the server, TLSCertFile and TLSKeyFile authenticate
+	// the client. Setting any of them enables TLS.
+	TLSCAFile   string `env:"MONGO_TLS_CA_FILE"`
+	TLSCertFile string `env:"MONGO_TLS_CERT_FILE"`
+	TLSKeyFile  string `env:"MONGO_TLS_KEY_FILE"`
+
+	MaxPoolSize uint64 `env:"MONGO_MAX_POOL_SIZE" envDefault:"100"`
+	MinPoolSize uint64 `env:"MONGO_MIN_POOL_SIZE"`
+	// ReadConcern is local, majority, linearizable, available or snapshot.
+	// Empty leaves the server default.
+	ReadConcern string `env:"MONGO_READ_CONCERN"`
+	// WriteConcern is majority, a number of nodes or a tag set name. Empty
+	// leaves the server default.
+	WriteConcern string `env:"MONGO_WRITE_CONCERN"`
+	// Timeout bounds every operation, on top of the request context.
+	Timeout        time.Duration `env:"MONGO_TIMEOUT" envDefault:"10s"`
+	ConnectTimeout time.Duration `env:"MONGO_CONNECT_TIMEOUT" envDefault:"10s"`
+	// MigrateOnStart applies pending migrations when the server starts.
+	// Without it they have to be applied with the migrate command.
+	MigrateOnStart bool `env:"MONGO_MIGRATE_ON_START" envDefault:"true"`
+}
+
+type AuthConfig struct {
+	AccessKeyFile       string        `env:"AUTH_ACCESS_KEY_FILE"`
+	RefreshKeyFile      string        `env:"AUTH_REFRESH_KEY_FILE"`
+	AccessKeysDir       string        `env:"AUTH_ACCESS_KEYS_DIR"`
+	RefreshKeysDir      string        `env:"AUTH_REFRESH_KEYS_DIR"`
+	KeyRotationInterval time.Duration `env:"AUTH_KEY_ROTATION_INTERVAL"`
+	AccessTokenTTL      time.Duration `env:"AUTH_ACCESS_TOKEN_TTL" envDefault:"1h"`
+	RefreshTokenTTL     time.Duration `env:"AUTH_REFRESH_TOKEN_TTL" envDefault:"168h"`
 }
 
-func NewFromEnv() (*Config, error) {
-	var config Config
-	if err := env.Parse(&config); err != nil {
-		return nil, err
+// MailConfig is the SMTP server password recovery emails are sent through.
+// Without a Host no emails are sent.
+type MailConfig struct {
+	Host     string `env:"MAIL_HOST"`
+	Port     int    `env:"MAIL_PORT" envDefault:"587"`
+	Username string `env:"MAIL_USERNAME"`
+	Password string `env:"MAIL_PASSWORD" secret:"true"`
+	// From is the sender address, Username by default.
+	From string `env:"MAIL_FROM"`
+	// RecoveryURL is the page the recovery link points to, the token is
+	// added as the token query parameter.
+	RecoveryURL string `env:"MAIL_RECOVERY_URL" envDefault:"http://localhost:8080/api/v1/recoverPassword"`
+}
+
+// Sender is the From address of the emails.
+func (c *MailConfig) Sender() string {
+	if c.From != "" {
+		return c.From
 	}
 
-	return &config, nil
+	return c.Username
+}
+
+// CORSConfig is the CORS policy of the API. An AllowedOrigins entry of "*"
+// allows every origin, otherwise the request origin is echoed if listed.
+// Browsers refuse credentials for "*", so AllowCredentials needs a list.
+type CORSConfig struct {
+	AllowedOrigins   []string `env:"CORS_ALLOWED_ORIGINS" envDefault:"*"`
+	AllowedMethods   []string `env:"CORS_ALLOWED_METHODS" envDefault:"POST,OPTIONS,GET,PUT,PATCH,DELETE"`
+	AllowedHeaders   []string `env:"CORS_ALLOWED_HEADERS" envDefault:"Content-Type,Content-Length,Accept-Encoding,X-CSRF-Token,Authorization,accept,origin,Cache-Control,X-Requested-With,If-Match,If-None-Match,X-Request-ID,traceparent,tracestate"`
+	ExposedHeaders   []string `env:"CORS_EXPOSED_HEADERS" envDefault:"ETag,X-Request-ID"`
+	AllowCredentials bool     `env:"CORS_ALLOW_CREDENTIALS"`
+	// MaxAge lets browsers cache preflight results, 0 leaves it to them.
+	MaxAge time.Duration `env:"CORS_MAX_AGE"`
+}
+
+// LogConfig selects how the service logs. Format is text or json, Level is
+// debug, info, warn or error.
+type LogConfig struct {
+	Format string `env:"LOG_FORMAT" envDefault:"text"`
+	Level  string `env:"LOG_LEVEL" envDefault:"info"`
+}
+
+const (
+	TraceExporterNone   = "none"
+	TraceExporterOTLP   = "otlp"
+	TraceExporterStdout = "stdout"
+	TraceExporterFile   = "file"
+)
+
+// TraceConfig selects where OpenTelemetry spans go. The otlp exporter sends
+// them over HTTP to OTLPEndpoint, stdout and file write them as JSON.
+type TraceConfig struct {
+	Exporter     string `env:"TRACE_EXPORTER" envDefault:"none"`
+	OTLPEndpoint string `env:"TRACE_OTLP_ENDPOINT" envDefault:"localhost:4318"`
+	OTLPInsecure bool   `env:"TRACE_OTLP_INSECURE"`
+	File         string `env:"TRACE_FILE"`
+	// SampleRatio is the share of new traces recorded, requests that come
+	// with a sampled traceparent are always recorded.
+	SampleRatio float64 `env:"TRACE_SAMPLE_RATIO" envDefault:"1"`
 }
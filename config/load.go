@@ -0,0 +1,192 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/caarlos0/env/v6"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// FileEnv names the config file when the -config flag is not given.
+const FileEnv = "CONFIG_FILE"
+
+// New loads the configuration of the process, see Load.
+func New() (*Config, []string, error) {
+	return Load(os.Args[1:], os.Environ(), os.Stderr)
+}
+
+// Load builds the configuration from, in increasing precedence, the
+// defaults, a YAML or TOML file named by the -config flag or CONFIG_FILE,
+// the environment variables and the flags in args. It returns the
+// arguments left after the flags. Every problem found, from unknown file
+// settings to invalid values, is reported in the error. Flag usage is
+// written to usage.
+func Load(args, environ []string, usage io.Writer) (*Config, []string, error) {
+	flags := flag.NewFlagSet(filepath.Base(os.Args[0]), flag.ContinueOnError)
+	flags.SetOutput(usage)
+	file := flags.String("config", "", "YAML or TOML config file, "+FileEnv+" by default")
+	flagValues := make(map[string]string)
+	for _, s := range settings() {
+		flags.Var(&flagValue{setting: s, values: flagValues}, s.key, s.env)
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	environment := make(map[string]string, len(environ))
+	for _, pair := range environ {
+		if name, value, ok := strings.Cut(pair, "="); ok {
+			environment[name] = value
+		}
+	}
+	if *file == "" {
+		*file = environment[FileEnv]
+	}
+
+	var errs []error
+	values := make(map[string]string)
+	if *file != "" {
+		fileValues, err := readFile(*file)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		for name, value := range fileValues {
+			values[name] = value
+		}
+	}
+	for name, value := range environment {
+		values[name] = value
+	}
+	for name, value := range flagValues {
+		values[name] = value
+	}
+
+	var config Config
+	errs = append(errs, parse(&config, values)...)
+	if err := config.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if len(errs) > 0 {
+		return nil, nil, errors.Join(errs...)
+	}
+
+	return &config, flags.Args(), nil
+}
+
+// Default is the configuration with every setting at its default.
+func Default() *Config {
+	var config Config
+	_ = parse(&config, map[string]string{})
+
+	return &config
+}
+
+// parse sets the fields of config from values by environment variable name,
+// falling back to the defaults. Errors name the section of the field.
+func parse(config *Config, values map[string]string) []error {
+	var errs []error
+	sections := reflect.ValueOf(config).Elem()
+	for i := 0; i < sections.NumField(); i++ {
+		section := sections.Field(i).Addr().Interface()
+		if err := env.Parse(section, env.Options{Environment: values}); err != nil {
+			name := strings.ToLower(sections.Type().Field(i).Name)
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+
+	return errs
+}
+
+// readFile reads the settings of a config file by environment variable
+// name, formatted as they would be in the environment.
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var tree map[string]interface{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &tree)
+	case ".toml":
+		err = toml.Unmarshal(data, &tree)
+	default:
+		return nil, fmt.Errorf("%s: unknown config file format, use .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	byKey := settingsByKey()
+	values := make(map[string]string)
+	var errs []error
+	for section, fields := range tree {
+		fields, ok := fields.(map[string]interface{})
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: %s is not a section", path, section))
+
+			continue
+		}
+		for name, value := range fields {
+			s, ok := byKey[section+"."+name]
+			if !ok {
+				errs = append(errs, fmt.Errorf("%s: unknown setting %s.%s", path, section, name))
+
+				continue
+			}
+			if value != nil {
+				values[s.env] = fileValue(value)
+			}
+		}
+	}
+
+	return values, joinSorted(errs)
+}
+
+// fileValue formats a decoded file value the way env parses it, lists are
+// comma separated.
+func fileValue(value interface{}) string {
+	switch value := value.(type) {
+	case []interface{}:
+		items := make([]string, len(value))
+		for i, item := range value {
+			items[i] = fileValue(item)
+		}
+
+		return strings.Join(items, ",")
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	}
+
+	return fmt.Sprint(value)
+}
+
+// flagValue records a setting given on the command line.
+type flagValue struct {
+	setting setting
+	values  map[string]string
+}
+
+func (f *flagValue) String() string {
+	return f.setting.def
+}
+
+func (f *flagValue) Set(value string) error {
+	f.values[f.setting.env] = value
+
+	return nil
+}
+
+func (f *flagValue) IsBoolFlag() bool {
+	return f.setting.isBool
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Redacted replaces the value of secret settings when printed.
+const Redacted = "[redacted]"
+
+// Print writes the configuration as a YAML config file, with the values of
+// secret settings redacted.
+func (c *Config) Print(w io.Writer) error {
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	var section *yaml.Node
+	value := reflect.ValueOf(c).Elem()
+	for _, s := range settings() {
+		if section == nil || root.Content[len(root.Content)-2].Value != s.section() {
+			section = &yaml.Node{Kind: yaml.MappingNode}
+			root.Content = append(root.Content, scalar(s.section()), section)
+		}
+
+		field := value.FieldByIndex(s.index)
+		node := valueNode(field)
+		if s.secret && !field.IsZero() {
+			node = scalar(Redacted)
+		}
+		section.Content = append(section.Content, scalar(s.name()), node)
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(root); err != nil {
+		return err
+	}
+
+	return encoder.Close()
+}
+
+func scalar(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
+
+// valueNode formats a setting the way Load reads it back.
+func valueNode(field reflect.Value) *yaml.Node {
+	switch value := field.Interface().(type) {
+	case time.Duration:
+		return scalar(value.String())
+	case []string:
+		list := &yaml.Node{Kind: yaml.SequenceNode, Style: yaml.FlowStyle}
+		for _, item := range value {
+			list.Content = append(list.Content, scalar(item))
+		}
+
+		return list
+	}
+
+	if field.Kind() == reflect.String {
+		return scalar(field.String())
+	}
+
+	// Numbers and booleans are left untagged so they print unquoted.
+	return &yaml.Node{Kind: yaml.ScalarNode, Value: fmt.Sprint(field.Interface())}
+}
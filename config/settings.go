@@ -0,0 +1,70 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+)
+
+// setting is one field of Config and the names it goes by.
+type setting struct {
+	// key is the name in config files and flags, e.g. mongo.max_pool_size.
+	key string
+	// env is the environment variable, e.g. MONGO_MAX_POOL_SIZE.
+	env    string
+	def    string
+	secret bool
+	isBool bool
+	index  []int
+}
+
+// section is the part of the key before the dot, the file section.
+func (s setting) section() string {
+	section, _, _ := strings.Cut(s.key, ".")
+
+	return section
+}
+
+// name is the part of the key after the dot.
+func (s setting) name() string {
+	_, name, _ := strings.Cut(s.key, ".")
+
+	return name
+}
+
+// settings lists every setting in the order of the Config fields. Sections
+// are named after their Config field, and settings after their environment
+// variable without the section prefix.
+func settings() []setting {
+	var list []setting
+	config := reflect.TypeOf(Config{})
+	for i := 0; i < config.NumField(); i++ {
+		section := config.Field(i)
+		name := strings.ToLower(section.Name)
+		for j := 0; j < section.Type.NumField(); j++ {
+			field := section.Type.Field(j)
+			env := field.Tag.Get("env")
+			if env == "" {
+				continue
+			}
+			list = append(list, setting{
+				key:    name + "." + strings.ToLower(strings.TrimPrefix(env, strings.ToUpper(name)+"_")),
+				env:    env,
+				def:    field.Tag.Get("envDefault"),
+				secret: field.Tag.Get("secret") == "true",
+				isBool: field.Type.Kind() == reflect.Bool,
+				index:  []int{i, j},
+			})
+		}
+	}
+
+	return list
+}
+
+func settingsByKey() map[string]setting {
+	byKey := make(map[string]setting)
+	for _, s := range settings() {
+		byKey[s.key] = s
+	}
+
+	return byKey
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"bookService/config"
+	"errors"
+	"io"
+)
+
+const configUsage = `usage: bookService [flags] config print`
+
+// runConfig is the config command. config print writes the effective
+// configuration, after the file, environment and flags were applied, with
+// secrets redacted.
+func runConfig(conf *config.Config, args []string, out io.Writer) error {
+	if len(args) != 1 || args[0] != "print" {
+		return errors.New(configUsage)
+	}
+
+	return conf.Print(out)
+}
@@ -0,0 +1,29 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRun(t *testing.T) {
+	ok := Check{Name: "ok", Probe: func(context.Context) error { return nil }}
+	broken := Check{Name: "broken", Probe: func(context.Context) error { return errors.New("down") }}
+	stuck := Check{Name: "stuck", Probe: func(context.Context) error {
+		time.Sleep(time.Second)
+
+		return nil
+	}}
+
+	report := Run(context.Background(), time.Second, []Check{ok})
+	assert.Equal(t, StatusOK, report.Status)
+
+	report = Run(context.Background(), 20*time.Millisecond, []Check{ok, broken, stuck})
+	assert.Equal(t, StatusFailing, report.Status)
+	assert.Equal(t, StatusOK, report.Checks[0].Status)
+	assert.Equal(t, "down", report.Checks[1].Error)
+	assert.Equal(t, context.DeadlineExceeded.Error(), report.Checks[2].Error)
+}
@@ -0,0 +1,70 @@
+package http
+
+import (
+	"bookService/auth"
+	"bookService/model"
+	"bookService/policy"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// subject builds the policy subject of the caller authorized by
+// auth.Middleware.Authorize.
+func subject(c *gin.Context) (policy.Subject, bool) {
+	claims, ok := auth.ClaimsFromContext(c)
+	if !ok {
+		return policy.Subject{}, false
+	}
+	user, _ := auth.UserFromContext(c)
+
+	return policy.Subject{
+		ID:        claims.BaseClaims.ID,
+		Role:      claims.Role,
+		LibraryID: user.LibraryID,
+	}, true
+}
+
+// authorize evaluates the policy for the caller and writes the error
+// response when the action is not allowed. Denials are audited.
+func (a *api) authorize(c *gin.Context, action policy.Action, resource policy.Resource) bool {
+	sub, ok := subject(c)
+	if !ok {
+		logger(c).Info("authorize empty claims")
+		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return false
+	}
+
+	if err := policy.Authorize(sub, action, resource); err != nil {
+		logger(c).Warn("authorize denied", "action", action, "kind", resource.Kind(), "user_id", sub.ID, "err", err)
+		a.audit(c, model.AuditEvent{
+			Action:  model.AuditAccessDenied,
+			Target:  auditTarget(resource),
+			Outcome: model.AuditDenied,
+			Reason:  string(action),
+		})
+		c.JSON(http.StatusForbidden, model.ErrForbidden)
+
+		return false
+	}
+
+	return true
+}
+
+// auditTarget names a resource for the audit log, resources that are not
+// stored yet only by their kind.
+func auditTarget(resource policy.Resource) string {
+	var ID uint64
+	switch r := resource.(type) {
+	case policy.Book:
+		ID = r.ID
+	case policy.Author:
+		ID = r.ID
+	}
+	if ID == 0 {
+		return resource.Kind()
+	}
+
+	return model.AuditTarget(resource.Kind(), ID)
+}
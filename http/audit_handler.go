@@ -0,0 +1,168 @@
+package http
+
+import (
+	"bookService/model"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// exportPageSize is how many events the export reads from the store at a
+// time.
+const exportPageSize = 500
+
+type AuditHandlerInterface interface {
+	GetAll(c *gin.Context)
+	Export(c *gin.Context)
+}
+
+// AuditHandler is the admin API for the audit log.
+type AuditHandler struct {
+	api *api
+}
+
+func NewAuditHandler(a *api) *AuditHandler {
+	return &AuditHandler{
+		api: a,
+	}
+}
+
+// GetAll lists audit events, the newest first. The next page starts before
+// the id in next:
+// GET /audit?actor=&action=&outcome=&target=&since=&until=&before=&limit=
+func (h *AuditHandler) GetAll(c *gin.Context) {
+	query, err := auditQuery(c)
+	if err != nil {
+		logger(c).Info("GetAll auditQuery", "err", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	results, err := h.api.store.Audit().Search(c.Request.Context(), query)
+	if err != nil {
+		logger(c).Error("GetAll Search", "err", err)
+		c.JSON(http.StatusInternalServerError, model.ErrInternalServerError)
+
+		return
+	}
+
+	answer := map[string]interface{}{
+		"items": results,
+		"limit": query.Limit,
+	}
+	if len(results) == query.Limit {
+		answer["next"] = strconv.FormatUint(results[len(results)-1].ID, DecimalBase)
+	}
+
+	c.JSON(http.StatusOK, answer)
+}
+
+// Export streams every matching event as JSON lines, the newest first. It
+// takes the filters of GetAll, limit and before excepted:
+// GET /audit/export
+func (h *AuditHandler) Export(c *gin.Context) {
+	query, err := auditQuery(c)
+	if err != nil {
+		logger(c).Info("Export auditQuery", "err", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+	query.BeforeID = 0
+	query.Limit = exportPageSize
+
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Header("Content-Disposition", `attachment; filename="audit.jsonl"`)
+	c.Status(http.StatusOK)
+
+	encoder := json.NewEncoder(c.Writer)
+	for {
+		page, err := h.api.store.Audit().Search(c.Request.Context(), query)
+		if err != nil {
+			// The status is sent already, the client sees a cut off export.
+			logger(c).Info("Export Search", "err", err)
+
+			return
+		}
+		for _, event := range page {
+			if err := encoder.Encode(event); err != nil {
+				logger(c).Info("Export Encode", "err", err)
+
+				return
+			}
+		}
+		c.Writer.Flush()
+
+		if len(page) < query.Limit {
+			return
+		}
+		query.BeforeID = page[len(page)-1].ID
+	}
+}
+
+func auditQuery(c *gin.Context) (model.AuditQuery, error) {
+	query := model.AuditQuery{
+		Action:  model.AuditAction(c.Query("action")),
+		Outcome: model.AuditOutcome(c.Query("outcome")),
+		Target:  c.Query("target"),
+		Limit:   defaultPageLimit,
+	}
+
+	var err error
+	if actor := c.Query("actor"); actor != "" {
+		if query.ActorID, err = strconv.ParseUint(actor, DecimalBase, BitSize64); err != nil {
+			return query, fmt.Errorf("invalid actor %q", actor)
+		}
+	}
+	if before := c.Query("before"); before != "" {
+		if query.BeforeID, err = strconv.ParseUint(before, DecimalBase, BitSize64); err != nil {
+			return query, fmt.Errorf("invalid before %q", before)
+		}
+	}
+	if since := c.Query("since"); since != "" {
+		if query.Since, err = time.Parse(time.RFC3339, since); err != nil {
+			return query, fmt.Errorf("invalid since %q", since)
+		}
+	}
+	if until := c.Query("until"); until != "" {
+		if query.Until, err = time.Parse(time.RFC3339, until); err != nil {
+			return query, fmt.Errorf("invalid until %q", until)
+		}
+	}
+	if limit := c.Query("limit"); limit != "" {
+		if query.Limit, err = strconv.Atoi(limit); err != nil || query.Limit <= 0 {
+			return query, fmt.Errorf("invalid limit %q", limit)
+		}
+		if query.Limit > maxPageLimit {
+			query.Limit = maxPageLimit
+		}
+	}
+
+	return query, nil
+}
+
+// audit records an event about the current request. The caller fills in
+// the action, outcome and target, and the actor when the request is not
+// authenticated. A failure to record is logged and does not fail the
+// request.
+func (a *api) audit(c *gin.Context, event model.AuditEvent) {
+	if sub, ok := subject(c); ok && event.ActorID == 0 {
+		event.ActorID = sub.ID
+	}
+	event.Time = time.Now().UTC()
+	event.IP = c.ClientIP()
+	event.UserAgent = c.Request.UserAgent()
+	event.RequestID = requestID(c)
+
+	// The request context is done when the client hangs up, the event
+	// should be kept anyway.
+	if err := a.store.Audit().Insert(context.Background(), event); err != nil {
+		logger(c).Error("audit Insert", "action", event.Action, "err", err)
+	}
+}
@@ -0,0 +1,140 @@
+package http
+
+import (
+	"bookService/mocks"
+	"bookService/model"
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditLog(t *testing.T) {
+	api, memory := newTestAPI(t)
+	ctx := context.Background()
+
+	signIn(t, api, "admin@example.com")
+	admin, err := memory.Users().GetByLogin(ctx, "admin@example.com")
+	assert.NoError(t, err)
+	assert.NoError(t, memory.Users().SetRole(ctx, admin.ID, model.RoleAdmin))
+	creds := model.Credentials{Login: "admin@example.com", Password: "secret"}
+	rr := serveJSON(api, "POST", "/api/v1/signIn", "", creds)
+	var answer struct {
+		AccessToken string `json:"accessToken"`
+	}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &answer))
+	adminToken := answer.AccessToken
+
+	token := signIn(t, api, "writer@example.com")
+	writer, err := memory.Users().GetByLogin(ctx, "writer@example.com")
+	assert.NoError(t, err)
+	rr = serveJSON(api, "POST", "/api/v1/signIn", "", model.Credentials{Login: "writer@example.com", Password: "wrong"},
+		"User-Agent", "test-agent", "X-Request-ID", "req-1")
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	book, err := memory.Books().Insert(ctx, model.Book{Name: "Dune"}, admin.ID)
+	assert.NoError(t, err)
+	rr = serveJSON(api, "DELETE", "/api/v1/book/"+book.PublicID(), token, nil, "If-Match", book.ETag())
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	rr = serveJSON(api, "GET", "/api/v1/audit", token, nil)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	var events struct {
+		Items []model.AuditEvent `json:"items"`
+		Next  string             `json:"next"`
+	}
+	rr = serveJSON(api, "GET", "/api/v1/audit?outcome=failure", adminToken, nil)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &events))
+	assert.Len(t, events.Items, 1)
+	assert.Equal(t, model.AuditSignIn, events.Items[0].Action)
+	assert.Equal(t, writer.ID, events.Items[0].ActorID)
+	assert.Equal(t, "wrong password", events.Items[0].Reason)
+	assert.Equal(t, "test-agent", events.Items[0].UserAgent)
+	assert.Equal(t, "req-1", events.Items[0].RequestID)
+
+	rr = serveJSON(api, "GET", "/api/v1/audit?action=access.denied&target="+
+		model.AuditTarget("book", book.ID), adminToken, nil)
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &events))
+	assert.Len(t, events.Items, 1)
+	assert.Equal(t, writer.ID, events.Items[0].ActorID)
+	assert.Equal(t, model.AuditDenied, events.Items[0].Outcome)
+
+	rr = serveJSON(api, "GET", "/api/v1/audit?actor="+strconv.FormatUint(writer.ID, 10)+"&limit=1", adminToken, nil)
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &events))
+	assert.Len(t, events.Items, 1)
+	assert.Equal(t, model.AuditAccessDenied, events.Items[0].Action)
+	rr = serveJSON(api, "GET", "/api/v1/audit?actor="+strconv.FormatUint(writer.ID, 10)+"&limit=1&before="+events.Next, adminToken, nil)
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &events))
+	assert.Equal(t, model.AuditSignIn, events.Items[0].Action)
+	assert.Equal(t, model.AuditFailure, events.Items[0].Outcome)
+
+	rr = serveJSON(api, "GET", "/api/v1/audit?since=yesterday", adminToken, nil)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr = serveJSON(api, "GET", "/api/v1/audit/export", adminToken, nil)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/x-ndjson", rr.Header().Get("Content-Type"))
+	lines := 0
+	scanner := bufio.NewScanner(rr.Body)
+	for scanner.Scan() {
+		var event model.AuditEvent
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &event))
+		lines++
+	}
+	all, err := memory.Audit().Search(ctx, model.AuditQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, len(all), lines)
+}
+
+func TestAuditGetAllHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAuditHandler := mocks.NewMockAuditHandlerInterface(ctrl)
+
+	mockAuditHandler.EXPECT().GetAll(gomock.Any()).Return()
+
+	req, _ := http.NewRequest("GET", "/audit", nil)
+
+	router := gin.Default()
+	router.GET("/audit", func(c *gin.Context) {
+		mockAuditHandler.GetAll(c)
+	})
+	router.ServeHTTP(httptest.NewRecorder(), req)
+}
+
+func TestAuthEventsNameTheActor(t *testing.T) {
+	api, memory := newTestAPI(t)
+	ctx := context.Background()
+	token := signIn(t, api, "writer@example.com")
+	writer, err := memory.Users().GetByLogin(ctx, "writer@example.com")
+	assert.NoError(t, err)
+	_, refreshToken := startSession(t, api, "writer@example.com")
+
+	assert.Equal(t, http.StatusOK, refresh(api, refreshToken).Code)
+	assert.Equal(t, http.StatusUnauthorized, refresh(api, refreshToken).Code)
+	rr := serveJSON(api, "POST", "/api/v1/logout", token, nil)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	for _, query := range []model.AuditQuery{
+		{Action: model.AuditSignUp},
+		{Action: model.AuditRefresh, Outcome: model.AuditSuccess},
+		{Action: model.AuditRefresh, Outcome: model.AuditFailure},
+		{Action: model.AuditLogout},
+	} {
+		events, err := memory.Audit().Search(ctx, query)
+		assert.NoError(t, err)
+		if assert.Len(t, events, 1, query.Action) {
+			assert.Equal(t, writer.ID, events[0].ActorID, query.Action)
+			assert.Equal(t, writer.Login, events[0].ActorLogin, query.Action)
+		}
+	}
+}
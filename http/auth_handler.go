@@ -1,14 +1,20 @@
 package http
 
 import (
+	"bookService/auth"
+	"bookService/config"
 	"bookService/model"
+	"bookService/store"
 	"crypto/rand"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
+	"net"
 	"net/http"
 	"net/smtp"
+	"net/url"
 	"strconv"
 	"strings"
 
@@ -23,8 +29,11 @@ type AuthHandlerInterface interface {
 	SignIn(c *gin.Context)
 	SignUp(c *gin.Context)
 	Refresh(c *gin.Context)
+	Logout(c *gin.Context)
+	LogoutAll(c *gin.Context)
 	Recover(c *gin.Context)
 	SetNewPassword(c *gin.Context)
+	JWKS(c *gin.Context)
 }
 
 type AuthHandler struct {
@@ -38,10 +47,10 @@ func NewAuthHandler(a *api) *AuthHandler {
 }
 
 func (h *AuthHandler) SignIn(c *gin.Context) {
-	creds := &model.User{}
-	err := c.ShouldBindJSON(&creds)
+	creds := &model.Credentials{}
+	err := c.ShouldBindJSON(creds)
 	if err != nil {
-		log.Println("SignIn ShouldBindJSON err: ", err)
+		logger(c).Info("SignIn ShouldBindJSON", "err", err)
 		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
 
 		return
@@ -50,18 +59,20 @@ func (h *AuthHandler) SignIn(c *gin.Context) {
 	login := strings.TrimSpace(creds.Login)
 	pass := strings.TrimSpace(creds.Password)
 	if login == "" || pass == "" {
-		log.Println("SignIn Empty login or pass")
+		logger(c).Info("SignIn Empty login or pass")
 		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
 
 		return
 	}
 
-	user, err := h.api.mongo.UsersRepository.GetByLogin(creds.Login)
+	user, err := h.api.store.Users().GetByLogin(c.Request.Context(), creds.Login)
 	if err != nil {
-		log.Println("SignIn GetByLogin err: ", err)
-		if err.Error() == "not found" {
+		if err == store.ErrNotFound {
+			logger(c).Info("SignIn GetByLogin", "err", err)
+			h.failed(c, model.AuditSignIn, 0, login, "unknown login")
 			c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
 		} else {
+			logger(c).Error("SignIn GetByLogin", "err", err)
 			c.JSON(http.StatusInternalServerError, model.ErrInternalServerError)
 		}
 
@@ -70,19 +81,40 @@ func (h *AuthHandler) SignIn(c *gin.Context) {
 
 	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(creds.Password))
 	if err != nil {
-		log.Println("SignIn CompareHashAndPassword err: ", err)
+		logger(c).Info("SignIn CompareHashAndPassword", "err", err)
+		h.failed(c, model.AuditSignIn, user.ID, login, "wrong password")
 		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
 
 		return
 	}
 
-	tokens, err := h.api.auth.CreateTokens(user.ID)
+	if user.Locked {
+		logger(c).Info("SignIn user locked", "user_id", user.ID)
+		h.failed(c, model.AuditSignIn, user.ID, login, "user locked")
+		c.JSON(http.StatusForbidden, model.ErrUserLocked)
+
+		return
+	}
+
+	if user.PasswordResetRequired {
+		logger(c).Info("SignIn password reset required", "user_id", user.ID)
+		h.failed(c, model.AuditSignIn, user.ID, login, "password reset required")
+		c.JSON(http.StatusForbidden, model.ErrPasswordReset)
+
+		return
+	}
+
+	tokens, err := h.api.auth.StartSession(c.Request.Context(), *user, auth.SessionInfo{
+		UserAgent: c.Request.UserAgent(),
+		IP:        c.ClientIP(),
+	})
 	if err != nil {
-		log.Println("SignIn CreateTokens err: ", err)
+		logger(c).Error("SignIn StartSession", "err", err)
 		c.JSON(http.StatusInternalServerError, model.ErrInternalServerError)
 
 		return
 	}
+	h.succeeded(c, model.AuditSignIn, user.ID, login)
 
 	answer := map[string]interface{}{
 		"accessToken":  tokens.Access,
@@ -93,16 +125,16 @@ func (h *AuthHandler) SignIn(c *gin.Context) {
 }
 
 func (h *AuthHandler) SignUp(c *gin.Context) {
-	user := &model.User{}
+	user := &model.Credentials{}
 	if err := c.ShouldBindJSON(user); err != nil {
-		log.Println("SignUp ShouldBindJSON err: ", err)
+		logger(c).Info("SignUp ShouldBindJSON", "err", err)
 		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
 
 		return
 	}
 
 	if user.Login == "" || user.Password == "" {
-		log.Println("SignIn Empty login or pass")
+		logger(c).Info("SignIn Empty login or pass")
 		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
 
 		return
@@ -110,19 +142,27 @@ func (h *AuthHandler) SignUp(c *gin.Context) {
 
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
 	if err != nil {
-		log.Println("SignUp GenerateFromPassword err: ", err)
+		logger(c).Error("SignUp GenerateFromPassword", "err", err)
 		c.JSON(http.StatusInternalServerError, model.ErrInternalServerError)
 
 		return
 	}
-	user.Password = string(hashedPassword)
 
-	if err := h.api.mongo.UsersRepository.Insert(*user); err != nil {
-		log.Println("SignUp Insert err: ", err)
+	newUser := model.User{
+		Login:    user.Login,
+		Password: string(hashedPassword),
+		Role:     model.DefaultRole,
+	}
+
+	created, err := h.api.store.Users().Insert(c.Request.Context(), newUser)
+	if err != nil {
+		logger(c).Error("SignUp Insert", "err", err)
+		h.failed(c, model.AuditSignUp, 0, user.Login, err.Error())
 		c.JSON(http.StatusInternalServerError, model.ErrInternalServerError)
 
 		return
 	}
+	h.succeeded(c, model.AuditSignUp, created.ID, created.Login)
 
 	c.JSON(http.StatusOK, gin.H{"message": "user created successfully"})
 }
@@ -130,21 +170,70 @@ func (h *AuthHandler) SignUp(c *gin.Context) {
 func (h *AuthHandler) Refresh(c *gin.Context) {
 	refreshToken := c.PostForm("refreshToken")
 	if refreshToken == "" {
-		log.Println("Refresh empty refreshToken")
+		logger(c).Info("Refresh empty refreshToken")
 		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
 
 		return
 	}
 
-	newAccessToken, err := h.api.auth.Refresh(refreshToken)
+	tokens, userID, err := h.api.auth.Refresh(c.Request.Context(), refreshToken)
 	if err != nil {
-		log.Println("Refresh Refresh err: ", err)
+		logger(c).Info("Refresh Refresh", "err", err)
+		h.failed(c, model.AuditRefresh, userID, h.login(c, userID), err.Error())
 		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
 
 		return
 	}
+	h.succeeded(c, model.AuditRefresh, userID, h.login(c, userID))
+
+	answer := map[string]interface{}{
+		"accessToken":  tokens.Access,
+		"refreshToken": tokens.Refresh,
+	}
 
-	c.JSON(http.StatusOK, gin.H{"accessToken": newAccessToken})
+	c.JSON(http.StatusOK, answer)
+}
+
+func (h *AuthHandler) Logout(c *gin.Context) {
+	claims, ok := auth.ClaimsFromContext(c)
+	if !ok {
+		logger(c).Info("Logout ClaimsFromContext empty claims")
+		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return
+	}
+
+	if err := h.api.auth.Logout(c.Request.Context(), claims); err != nil {
+		logger(c).Error("Logout Logout", "err", err)
+		c.JSON(http.StatusInternalServerError, model.ErrInternalServerError)
+
+		return
+	}
+	user, _ := auth.UserFromContext(c)
+	h.succeeded(c, model.AuditLogout, claims.BaseClaims.ID, user.Login)
+
+	c.JSON(http.StatusOK, gin.H{"message": "logged out successfully"})
+}
+
+func (h *AuthHandler) LogoutAll(c *gin.Context) {
+	claims, ok := auth.ClaimsFromContext(c)
+	if !ok {
+		logger(c).Info("LogoutAll ClaimsFromContext empty claims")
+		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return
+	}
+
+	if err := h.api.auth.LogoutAll(c.Request.Context(), claims); err != nil {
+		logger(c).Error("LogoutAll LogoutAll", "err", err)
+		c.JSON(http.StatusInternalServerError, model.ErrInternalServerError)
+
+		return
+	}
+	user, _ := auth.UserFromContext(c)
+	h.succeeded(c, model.AuditLogoutAll, claims.BaseClaims.ID, user.Login)
+
+	c.JSON(http.StatusOK, gin.H{"message": "all sessions logged out successfully"})
 }
 
 func (h *AuthHandler) Recover(c *gin.Context) {
@@ -153,37 +242,37 @@ func (h *AuthHandler) Recover(c *gin.Context) {
 	}
 	err := c.ShouldBindJSON(&emailRequest)
 	if err != nil {
-		log.Println("Recover ShouldBindJSON err: ", err)
+		logger(c).Info("Recover ShouldBindJSON", "err", err)
 		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
 
 		return
 	}
 
-	user, err := h.api.mongo.UsersRepository.GetByLogin(emailRequest.Email)
+	user, err := h.api.store.Users().GetByLogin(c.Request.Context(), emailRequest.Email)
 	if err != nil {
-		log.Println("Recover GetByLogin err: ", err)
+		logger(c).Error("Recover GetByLogin", "err", err)
+		h.failed(c, model.AuditRecover, 0, emailRequest.Email, "unknown login")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "user not found"})
 
 		return
 	}
 
 	recoveryToken, err := generateRecoveryToken()
-	err = h.api.mongo.UsersRepository.SaveRecoveryToken(user.ID, recoveryToken)
+	err = h.api.store.Users().SaveRecoveryToken(c.Request.Context(), user.ID, recoveryToken)
 	if err != nil {
-		log.Println("Recover SaveRecoveryToken err: ", err)
+		logger(c).Error("Recover SaveRecoveryToken", "err", err)
 		c.JSON(http.StatusInternalServerError, model.ErrInternalServerError)
 
 		return
 	}
+	h.succeeded(c, model.AuditRecover, user.ID, emailRequest.Email)
 
-	go func() {
-		err := sendRecoveryEmail(emailRequest.Email, recoveryToken)
-		if err != nil {
-			log.Println("Recover sendRecoveryEmail err: ", err)
-
-			return
+	log := logger(c)
+	h.api.tasks.Go(func() {
+		if err := sendRecoveryEmail(h.api.conf.Mail, emailRequest.Email, recoveryToken); err != nil {
+			log.Info("Recover sendRecoveryEmail", "err", err)
 		}
-	}()
+	})
 
 	c.JSON(http.StatusOK, gin.H{"message": "recovery email sent"})
 }
@@ -191,9 +280,10 @@ func (h *AuthHandler) Recover(c *gin.Context) {
 func (h *AuthHandler) SetNewPassword(c *gin.Context) {
 	recoveryToken := c.Param("token")
 
-	userID, err := h.api.mongo.UsersRepository.VerifyRecoveryToken(recoveryToken)
+	userID, err := h.api.store.Users().VerifyRecoveryToken(c.Request.Context(), recoveryToken)
 	if err != nil {
-		log.Println("SetNewPassword VerifyRecoveryToken err:", err)
+		logger(c).Info("SetNewPassword VerifyRecoveryToken", "err", err)
+		h.failed(c, model.AuditPasswordReset, 0, "", "invalid recovery token")
 		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
 
 		return
@@ -203,7 +293,7 @@ func (h *AuthHandler) SetNewPassword(c *gin.Context) {
 		Password string `json:"password"`
 	}
 	if err := c.ShouldBindJSON(&newPasswordRequest); err != nil {
-		log.Println("SetNewPassword ShouldBindJSON err: ", err)
+		logger(c).Info("SetNewPassword ShouldBindJSON", "err", err)
 		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
 
 		return
@@ -211,23 +301,68 @@ func (h *AuthHandler) SetNewPassword(c *gin.Context) {
 
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPasswordRequest.Password), bcrypt.DefaultCost)
 	if err != nil {
-		log.Println("SetNewPassword GenerateFromPassword err: ", err)
+		logger(c).Error("SetNewPassword GenerateFromPassword", "err", err)
 		c.JSON(http.StatusInternalServerError, model.ErrInternalServerError)
 
 		return
 	}
 
-	err = h.api.mongo.UsersRepository.SetPassword(userID, string(hashedPassword))
+	err = h.api.store.Users().SetPassword(c.Request.Context(), userID, string(hashedPassword))
 	if err != nil {
-		log.Println("SetNewPassword SetPassword err: ", err)
+		logger(c).Error("SetNewPassword SetPassword", "err", err)
 		c.JSON(http.StatusInternalServerError, model.ErrInternalServerError)
 
 		return
 	}
+	h.succeeded(c, model.AuditPasswordReset, userID, "")
 
 	c.JSON(http.StatusOK, gin.H{"message": "password updated successfully"})
 }
 
+func (h *AuthHandler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.api.auth.AccessKeys().JWKS())
+}
+
+// succeeded audits and counts a successful auth request. actorID and login are only
+// needed before the caller is authenticated.
+// login looks up the login of a user known only by id, for audit events. It
+// is empty when the user cannot be found.
+func (h *AuthHandler) login(c *gin.Context, userID uint64) string {
+	if userID == 0 {
+		return ""
+	}
+	user, err := h.api.store.Users().Find(c.Request.Context(), userID)
+	if err != nil {
+		logger(c).Info("login Find", "user_id", userID, "err", err)
+
+		return ""
+	}
+
+	return user.Login
+}
+
+func (h *AuthHandler) succeeded(c *gin.Context, action model.AuditAction, actorID uint64, login string) {
+	countAuth(action, model.AuditSuccess)
+	h.api.audit(c, model.AuditEvent{
+		ActorID:    actorID,
+		ActorLogin: login,
+		Action:     action,
+		Outcome:    model.AuditSuccess,
+	})
+}
+
+func (h *AuthHandler) failed(c *gin.Context, action model.AuditAction, actorID uint64, login, reason string) {
+	countAuth(action, model.AuditFailure)
+	h.api.audit(c, model.AuditEvent{
+		ActorID:    actorID,
+		ActorLogin: login,
+		Action:     action,
+		Outcome:    model.AuditFailure,
+		Reason:     reason,
+	})
+}
+
 func IsPasswordMatch(password, hashedPassword string) bool {
 	userPasswordHash := H3hash(password + salt)
 
@@ -237,7 +372,7 @@ func IsPasswordMatch(password, hashedPassword string) bool {
 func H3hash(s string) string {
 	h3 := sha3.New512()
 	if _, err := io.WriteString(h3, s); err != nil {
-		log.Printf("H3hash WriteString err: %v", err)
+		slog.Error("H3hash WriteString", "err", err)
 	}
 
 	return fmt.Sprintf("%x", h3.Sum(nil))
@@ -257,28 +392,39 @@ func generateRecoveryToken() (string, error) {
 	return tokenHex, nil
 }
 
-func sendRecoveryEmail(email, recoveryToken string) error {
-	smtpHost := "smtp.gmail.com"
-	smtpPort := 587
-	smtpUsername := "testSendEmail456@gmail.com"
-	smtpPassword := "ulnsooneferjalvw"
+// errMailDisabled is returned for emails that are not sent as no SMTP server
+// is configured.
+var errMailDisabled = errors.New("MAIL_HOST is not set")
+
+func sendRecoveryEmail(conf config.MailConfig, email, recoveryToken string) error {
+	if conf.Host == "" {
+		return errMailDisabled
+	}
+
+	link, err := url.Parse(conf.RecoveryURL)
+	if err != nil {
+		return err
+	}
+	query := link.Query()
+	query.Set("token", recoveryToken)
+	link.RawQuery = query.Encode()
 
 	subject := "Password Recovery"
 	body := "Dear User,\n\nPlease click on the following link to reset your password:\n\n"
-	body += "http://localhost:8080/api/v1/recoverPassword?token=" + recoveryToken + "\n\n"
+	body += link.String() + "\n\n"
 	body += "Best regards,\nBook Service Team"
 
-	msg := "From: Book Service <" + smtpUsername + ">\n"
+	msg := "From: Book Service <" + conf.Sender() + ">\n"
 	msg += "To: " + email + "\n"
 	msg += "Subject: " + subject + "\n\n"
 	msg += body
 
-	auth := smtp.PlainAuth("", smtpUsername, smtpPassword, smtpHost)
-
-	err := smtp.SendMail(smtpHost+":"+strconv.Itoa(smtpPort), auth, smtpUsername, []string{email}, []byte(msg))
-	if err != nil {
-		return err
+	var auth smtp.Auth
+	if conf.Username != "" {
+		auth = smtp.PlainAuth("", conf.Username, conf.Password, conf.Host)
 	}
 
-	return nil
+	addr := net.JoinHostPort(conf.Host, strconv.Itoa(conf.Port))
+
+	return smtp.SendMail(addr, auth, conf.Sender(), []string{email}, []byte(msg))
 }
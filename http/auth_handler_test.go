@@ -2,9 +2,13 @@ package http
 
 import (
 	"bookService/auth"
+	"bookService/config"
+	"bookService/metrics"
 	"bookService/mocks"
 	"bookService/model"
+	"bookService/store"
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -12,54 +16,105 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 )
 
-type FakeAPI struct {
-	db   *FakeMongoStore
-	auth auth.Middleware
-}
+// newTestAPI serves the real handlers from an in-memory store.
+func newTestAPI(t *testing.T) (*api, *store.MemoryStore) {
+	t.Helper()
+
+	atKeys, err := auth.LoadKeySet("", "", auth.AccessTokenTTL)
+	assert.NoError(t, err)
+	rtKeys, err := auth.LoadKeySet("", "", auth.RefreshTokenTTL)
+	assert.NoError(t, err)
 
-func NewFakeAPI() *FakeAPI {
-	return &FakeAPI{
-		db:   &FakeMongoStore{},
-		auth: auth.Middleware{},
+	memory := store.NewMemoryStore()
+	api := &api{
+		conf:  config.Default(),
+		store: memory,
+		auth:  *auth.NewAuthMiddleware(atKeys, rtKeys, memory),
 	}
+	api.router = configureRouter(api)
+
+	return api, memory
 }
 
-type FakeMongoStore struct{}
+// serveJSON sends body to the router, headers are pairs of name and value.
+func serveJSON(api *api, method, path, token string, body interface{}, headers ...string) *httptest.ResponseRecorder {
+	var payload bytes.Buffer
+	if body != nil {
+		_ = json.NewEncoder(&payload).Encode(body)
+	}
 
-func (f *FakeMongoStore) GetByLogin(login string) (*model.User, error) {
-	return &model.User{}, nil
-}
+	req := httptest.NewRequest(method, path, &payload)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", token)
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
 
-func (f *FakeMongoStore) Insert(user model.User) error {
-	return nil
-}
+	rr := httptest.NewRecorder()
+	api.router.ServeHTTP(rr, req)
 
-func (f *FakeMongoStore) SaveRecoveryToken(userID uint64, token string) error {
-	return nil
+	return rr
 }
 
-func (f *FakeMongoStore) VerifyRecoveryToken(token string) (uint64, error) {
-	return 123, nil
-}
+// signIn signs up a new user and returns its access token.
+func signIn(t *testing.T, api *api, login string) string {
+	t.Helper()
+	creds := model.Credentials{Login: login, Password: "secret"}
+
+	rr := serveJSON(api, "POST", "/api/v1/signUp", "", creds)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	rr = serveJSON(api, "POST", "/api/v1/signIn", "", creds)
+	assert.Equal(t, http.StatusOK, rr.Code)
 
-type FakeJWTAuth struct{}
+	var answer struct {
+		AccessToken string `json:"accessToken"`
+	}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &answer))
 
-func (f *FakeJWTAuth) CreateTokens(userID uint64) (*auth.Tokens, error) {
-	return &auth.Tokens{
-		Access:  "fake_access_token",
-		Refresh: "fake_refresh_token",
-	}, nil
+	return answer.AccessToken
 }
 
-func (f *FakeJWTAuth) ParseToken(tokenString string) (uint64, error) {
-	return 123, nil
+func TestSignUpAndSignIn(t *testing.T) {
+	api, memory := newTestAPI(t)
+	creds := model.Credentials{Login: "reader@example.com", Password: "secret"}
+
+	rr := serveJSON(api, "POST", "/api/v1/signUp", "", creds)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	user, err := memory.Users().GetByLogin(context.Background(), creds.Login)
+	assert.NoError(t, err)
+	assert.NotEqual(t, creds.Password, user.Password)
+
+	rr = serveJSON(api, "POST", "/api/v1/signIn", "", creds)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	creds.Password = "wrong"
+	rr = serveJSON(api, "POST", "/api/v1/signIn", "", creds)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
 }
 
-func ConvertToAPI(fakeAPI *FakeAPI) *api {
-	return &api{}
+func TestMetrics(t *testing.T) {
+	api, _ := newTestAPI(t)
+	failures := metrics.SignIns.WithLabelValues(metrics.OutcomeFailure)
+	before := testutil.ToFloat64(failures)
+
+	creds := model.Credentials{Login: "nobody@example.com", Password: "secret"}
+	rr := serveJSON(api, "POST", "/api/v1/signIn", "", creds)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	assert.Equal(t, before+1, testutil.ToFloat64(failures))
+
+	rr = serveJSON(api, "GET", "/metrics", "", nil)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	body := rr.Body.String()
+	assert.Contains(t, body, `bookservice_http_requests_total{method="POST",route="/api/v1/signIn",status="401"}`)
+	assert.Contains(t, body, "bookservice_auth_sign_ins_total")
+	assert.Contains(t, body, "go_goroutines")
 }
 
 func TestSignInHandler(t *testing.T) {
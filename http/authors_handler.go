@@ -0,0 +1,208 @@
+package http
+
+import (
+	"bookService/auth"
+	"bookService/model"
+	"bookService/policy"
+	"bookService/store"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuthorsHandlerInterface interface {
+	GetAll(c *gin.Context)
+	Add(c *gin.Context)
+	Find(c *gin.Context)
+	Update(c *gin.Context)
+	Delete(c *gin.Context)
+}
+
+type AuthorsHandler struct {
+	api *api
+}
+
+func NewAuthorsHandler(a *api) *AuthorsHandler {
+	return &AuthorsHandler{
+		api: a,
+	}
+}
+
+func (h *AuthorsHandler) GetAll(c *gin.Context) {
+	offset, limit, err := offsetLimit(c)
+	if err != nil {
+		logger(c).Info("GetAll offsetLimit", "err", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	results, total, err := h.api.store.Authors().Search(c.Request.Context(), c.Query("q"), offset, limit)
+	if err != nil {
+		logger(c).Error("GetAll Search", "err", err)
+		c.JSON(http.StatusInternalServerError, model.ErrInternalServerError)
+
+		return
+	}
+
+	answer := map[string]interface{}{
+		"items":  results,
+		"total":  total,
+		"offset": offset,
+		"limit":  limit,
+	}
+
+	c.JSON(http.StatusOK, answer)
+}
+
+func (h *AuthorsHandler) Add(c *gin.Context) {
+	claims, ok := auth.ClaimsFromContext(c)
+	if !ok {
+		logger(c).Info("Add ClaimsFromContext empty claims")
+		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return
+	}
+
+	var item model.Author
+	if err := c.ShouldBindJSON(&item); err != nil {
+		logger(c).Info("Add ShouldBindJSON", "err", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	item.CreatedBy = claims.BaseClaims.ID
+	if !h.api.authorize(c, policy.ActionCreate, policy.Author{Author: item}) {
+		return
+	}
+
+	if err := item.Validate(); err != nil {
+		logger(c).Info("Add Validate", "err", err)
+		c.JSON(http.StatusBadRequest, err)
+
+		return
+	}
+
+	item, err := h.api.store.Authors().Insert(c.Request.Context(), item)
+	if err != nil {
+		logger(c).Error("Add Insert", "err", err)
+		c.JSON(http.StatusInternalServerError, model.ErrInternalServerError)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "author created successfully", "item": item})
+}
+
+func (h *AuthorsHandler) Find(c *gin.Context) {
+	item, ok := h.author(c)
+	if !ok {
+		return
+	}
+
+	answer := map[string]interface{}{
+		"item": item,
+	}
+
+	c.JSON(http.StatusOK, answer)
+}
+
+func (h *AuthorsHandler) Update(c *gin.Context) {
+	var item model.Author
+	if err := c.ShouldBindJSON(&item); err != nil {
+		logger(c).Info("Update ShouldBindJSON", "err", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	existingAuthor, ok := h.author(c)
+	if !ok {
+		return
+	}
+
+	if !h.api.authorize(c, policy.ActionUpdate, policy.Author{Author: existingAuthor}) {
+		return
+	}
+
+	item.ID = existingAuthor.ID
+	item.CreatedBy = existingAuthor.CreatedBy
+	if err := item.Validate(); err != nil {
+		logger(c).Info("Update Validate", "err", err)
+		c.JSON(http.StatusBadRequest, err)
+
+		return
+	}
+
+	if err := h.api.store.Authors().Update(c.Request.Context(), item); err != nil {
+		logger(c).Error("Update Update", "err", err)
+		c.JSON(http.StatusInternalServerError, model.ErrInternalServerError)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "author updated successfully"})
+}
+
+// Delete removes an author no book refers to any more.
+func (h *AuthorsHandler) Delete(c *gin.Context) {
+	existingAuthor, ok := h.author(c)
+	if !ok {
+		return
+	}
+
+	if !h.api.authorize(c, policy.ActionDelete, policy.Author{Author: existingAuthor}) {
+		return
+	}
+
+	n, err := h.api.store.Books().CountByContributor(c.Request.Context(), existingAuthor.ID)
+	if err != nil {
+		logger(c).Error("Delete CountByContributor", "err", err)
+		c.JSON(http.StatusInternalServerError, model.ErrInternalServerError)
+
+		return
+	}
+	if n > 0 {
+		c.JSON(http.StatusConflict, model.ErrAuthorInUse)
+
+		return
+	}
+
+	if err := h.api.store.Authors().Delete(c.Request.Context(), existingAuthor.ID); err != nil {
+		logger(c).Error("Delete Delete", "err", err)
+		c.JSON(http.StatusInternalServerError, model.ErrInternalServerError)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "author deleted successfully"})
+}
+
+// author loads the author named by the id path parameter, writing the error
+// response if there is none.
+func (h *AuthorsHandler) author(c *gin.Context) (model.Author, bool) {
+	ID, err := strconv.ParseUint(c.Param("id"), DecimalBase, BitSize64)
+	if err != nil {
+		logger(c).Info("author ParseUint", "err", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return model.Author{}, false
+	}
+
+	item, err := h.api.store.Authors().Find(c.Request.Context(), ID)
+	if err != nil {
+		if err == store.ErrNotFound {
+			logger(c).Info("author Find", "err", err)
+			c.JSON(http.StatusNotFound, model.ErrNotFound)
+		} else {
+			logger(c).Error("author Find", "err", err)
+			c.JSON(http.StatusInternalServerError, model.ErrInternalServerError)
+		}
+
+		return model.Author{}, false
+	}
+
+	return item, true
+}
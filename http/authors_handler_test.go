@@ -0,0 +1,188 @@
+package http
+
+import (
+	"bookService/mocks"
+	"bookService/model"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetAllAuthorsHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAuthorsHandler := mocks.NewMockAuthorsHandlerInterface(ctrl)
+
+	mockAuthorsHandler.EXPECT().GetAll(gomock.Any()).Return()
+
+	req, _ := http.NewRequest("GET", "/authors", nil)
+
+	router := gin.Default()
+	router.GET("/authors", func(c *gin.Context) {
+		mockAuthorsHandler.GetAll(c)
+	})
+	router.ServeHTTP(httptest.NewRecorder(), req)
+}
+
+func TestAddAuthorHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAuthorsHandler := mocks.NewMockAuthorsHandlerInterface(ctrl)
+
+	mockAuthorsHandler.EXPECT().Add(gomock.Any()).Return()
+
+	req, _ := http.NewRequest("POST", "/author", nil)
+
+	router := gin.Default()
+	router.POST("/author", func(c *gin.Context) {
+		mockAuthorsHandler.Add(c)
+	})
+	router.ServeHTTP(httptest.NewRecorder(), req)
+}
+
+func TestFindAuthorHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAuthorsHandler := mocks.NewMockAuthorsHandlerInterface(ctrl)
+
+	mockAuthorsHandler.EXPECT().Find(gomock.Any()).Return()
+
+	req, _ := http.NewRequest("GET", "/author/123", nil)
+
+	router := gin.Default()
+	router.GET("/author/:id", func(c *gin.Context) {
+		mockAuthorsHandler.Find(c)
+	})
+	router.ServeHTTP(httptest.NewRecorder(), req)
+}
+
+func TestUpdateAuthorHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAuthorsHandler := mocks.NewMockAuthorsHandlerInterface(ctrl)
+
+	mockAuthorsHandler.EXPECT().Update(gomock.Any()).Return()
+
+	req, _ := http.NewRequest("PUT", "/author/123", nil)
+
+	router := gin.Default()
+	router.PUT("/author/:id", func(c *gin.Context) {
+		mockAuthorsHandler.Update(c)
+	})
+	router.ServeHTTP(httptest.NewRecorder(), req)
+}
+
+func TestDeleteAuthorHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAuthorsHandler := mocks.NewMockAuthorsHandlerInterface(ctrl)
+
+	mockAuthorsHandler.EXPECT().Delete(gomock.Any()).Return()
+
+	req, _ := http.NewRequest("DELETE", "/author/123", nil)
+
+	router := gin.Default()
+	router.DELETE("/author/:id", func(c *gin.Context) {
+		mockAuthorsHandler.Delete(c)
+	})
+	router.ServeHTTP(httptest.NewRecorder(), req)
+}
+
+// addAuthor creates an author through the API and returns it.
+func addAuthor(t *testing.T, api *api, token, name string) model.Author {
+	t.Helper()
+
+	rr := serveJSON(api, "POST", "/api/v1/author", token, model.Author{Name: name})
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var answer struct {
+		Item model.Author `json:"item"`
+	}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &answer))
+
+	return answer.Item
+}
+
+func TestDeleteReferencedAuthorIsRefused(t *testing.T) {
+	ctx := context.Background()
+	api, memory := newTestAPI(t)
+	token, librarian := signInAs(t, api, memory, "librarian@example.com", model.RoleLibrarian)
+	author := addAuthor(t, api, token, "Frank Herbert")
+	path := fmt.Sprintf("/api/v1/author/%d", author.ID)
+
+	book, err := memory.Books().Insert(ctx, model.Book{Name: "Dune", Contributors: []model.Contributor{
+		{AuthorID: author.ID, Role: model.ContributorAuthor},
+	}}, librarian.ID)
+	assert.NoError(t, err)
+	rr := serveJSON(api, "DELETE", path, token, nil)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	// A trashed book can still be restored, so it keeps its authors.
+	assert.NoError(t, memory.Books().Delete(ctx, book.ID, book.Version, librarian.ID))
+	rr = serveJSON(api, "DELETE", path, token, nil)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	_, err = memory.Books().Purge(ctx, time.Now().Add(time.Second))
+	assert.NoError(t, err)
+	rr = serveJSON(api, "DELETE", path, token, nil)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	rr = serveJSON(api, "GET", path, "", nil)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestBookWithUnknownContributorIsRejected(t *testing.T) {
+	api, memory := newTestAPI(t)
+	token, _ := signInAs(t, api, memory, "writer@example.com", model.RoleAuthor)
+	author := addAuthor(t, api, token, "Frank Herbert")
+
+	book := model.Book{Name: "Dune", Contributors: []model.Contributor{
+		{AuthorID: author.ID, Role: model.ContributorAuthor},
+		{AuthorID: author.ID + 1000, Role: model.ContributorEditor},
+	}}
+	rr := serveJSON(api, "POST", "/api/v1/book", token, book)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "unknown author_id")
+
+	book.Contributors = book.Contributors[:1]
+	rr = serveJSON(api, "POST", "/api/v1/book", token, book)
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestAuthorWritesFollowPolicy(t *testing.T) {
+	api, memory := newTestAPI(t)
+	writerToken, _ := signInAs(t, api, memory, "writer@example.com", model.RoleAuthor)
+	otherToken, _ := signInAs(t, api, memory, "other@example.com", model.RoleAuthor)
+	readerToken, _ := signInAs(t, api, memory, "reader@example.com", model.RoleReader)
+	librarianToken, _ := signInAs(t, api, memory, "librarian@example.com", model.RoleLibrarian)
+
+	rr := serveJSON(api, "POST", "/api/v1/author", readerToken, model.Author{Name: "Frank Herbert"})
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	author := addAuthor(t, api, writerToken, "Frank Herbert")
+	path := fmt.Sprintf("/api/v1/author/%d", author.ID)
+	update := model.Author{Name: "Frank Patrick Herbert"}
+
+	rr = serveJSON(api, "PUT", path, otherToken, update)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	rr = serveJSON(api, "PUT", path, writerToken, update)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	rr = serveJSON(api, "PUT", path, librarianToken, update)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	rr = serveJSON(api, "DELETE", path, writerToken, nil)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	rr = serveJSON(api, "DELETE", path, librarianToken, nil)
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
@@ -1,23 +1,39 @@
 package http
 
 import (
+	"bookService/auth"
 	"bookService/model"
-	"log"
+	"bookService/policy"
+	"bookService/store"
+	"context"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
 
+	jsonpatch "github.com/evanphx/json-patch/v5"
 	"github.com/gin-gonic/gin"
 )
 
 const DecimalBase = 10
 const BitSize64 = 64
 
+const (
+	defaultBooksLimit = 20
+	maxBooksLimit     = 100
+)
+
 type BooksHandlerInterface interface {
 	GetAll(c *gin.Context)
 	Add(c *gin.Context)
 	Find(c *gin.Context)
 	Update(c *gin.Context)
+	Patch(c *gin.Context)
 	Delete(c *gin.Context)
+	Trash(c *gin.Context)
+	Restore(c *gin.Context)
 }
 
 type BooksHandler struct {
@@ -29,69 +45,168 @@ func NewBooksHandler(a *api) *BooksHandler {
 		api: a,
 	}
 }
+
+// GetAll searches the catalog:
+// GET /books?q=&author_id=&owner_id=&isbn=&language=&genre=&sort=name&order=desc&limit=&cursor=&count=true
 func (h *BooksHandler) GetAll(c *gin.Context) {
-	results, err := h.api.mongo.BooksRepository.GetAll()
+	query, err := bookQuery(c)
 	if err != nil {
-		log.Println("GetAll GetAll err: ", err)
+		logger(c).Info("GetAll bookQuery", "err", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	page, err := h.api.store.Books().Search(c.Request.Context(), query)
+	if err == store.ErrInvalidCursor {
+		logger(c).Info("GetAll Search", "err", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+	if err != nil {
+		logger(c).Error("GetAll Search", "err", err)
 		c.JSON(http.StatusInternalServerError, model.ErrInternalServerError)
 
 		return
 	}
-	answer := map[string]interface{}{
-		"items": results,
+
+	c.JSON(http.StatusOK, page)
+}
+
+func bookQuery(c *gin.Context) (model.BookQuery, error) {
+	query := model.BookQuery{
+		Text:   strings.TrimSpace(c.Query("q")),
+		Sort:   c.DefaultQuery("sort", model.BookSortID),
+		Limit:  defaultBooksLimit,
+		Cursor: c.Query("cursor"),
 	}
 
-	c.JSON(http.StatusOK, answer)
+	if query.Sort != model.BookSortID && query.Sort != model.BookSortName {
+		return query, fmt.Errorf("unknown sort %q", query.Sort)
+	}
+
+	switch order := c.DefaultQuery("order", "asc"); order {
+	case "asc":
+	case "desc":
+		query.Desc = true
+	default:
+		return query, fmt.Errorf("unknown order %q", order)
+	}
+
+	if isbn := c.Query("isbn"); isbn != "" {
+		normalized, err := model.NormalizeISBN(isbn)
+		if err != nil {
+			return query, err
+		}
+		query.ISBN = normalized
+	}
+	query.Language = strings.ToLower(c.Query("language"))
+	query.Genre = strings.ToLower(c.Query("genre"))
+
+	if authorID := c.Query("author_id"); authorID != "" {
+		ID, err := strconv.ParseUint(authorID, DecimalBase, BitSize64)
+		if err != nil {
+			return query, err
+		}
+		query.AuthorID = ID
+	}
+
+	if ownerID := c.Query("owner_id"); ownerID != "" {
+		ID, err := strconv.ParseUint(ownerID, DecimalBase, BitSize64)
+		if err != nil {
+			return query, err
+		}
+		query.OwnerID = ID
+	}
+
+	if limit := c.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 {
+			return query, fmt.Errorf("invalid limit %q", limit)
+		}
+		query.Limit = n
+	}
+	if query.Limit > maxBooksLimit {
+		query.Limit = maxBooksLimit
+	}
+
+	query.WithTotal = c.Query("count") == "true"
+
+	return query, nil
 }
 
 func (h *BooksHandler) Add(c *gin.Context) {
-	token := h.api.auth.ExtractToken(c.Request)
-	claims, err := h.api.auth.Validate(token)
-	if err != nil {
-		log.Println("Add Validate err: ", err)
+	claims, ok := auth.ClaimsFromContext(c)
+	if !ok {
+		logger(c).Info("Add ClaimsFromContext empty claims")
 		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
 
 		return
 	}
 
 	var item model.Book
-	err = c.ShouldBindJSON(&item)
+	err := c.ShouldBindJSON(&item)
 	if err != nil {
-		log.Println("Add ShouldBindJSON err: ", err)
+		logger(c).Info("Add ShouldBindJSON", "err", err)
 		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
 
 		return
 	}
 
-	err = h.api.mongo.BooksRepository.Insert(item, claims.BaseClaims.ID)
+	item.OwnerID = claims.BaseClaims.ID
+	if !h.api.authorize(c, policy.ActionCreate, policy.Book{Book: item}) {
+		return
+	}
+
+	if !h.validate(c, &item) {
+		return
+	}
+
+	item, err = h.api.store.Books().Insert(c.Request.Context(), item, claims.BaseClaims.ID)
+	if err == store.ErrDuplicate {
+		c.JSON(http.StatusConflict, model.ErrDuplicateISBN)
+
+		return
+	}
 	if err != nil {
-		log.Println("Add Insert err: ", err)
+		logger(c).Error("Add Insert", "err", err)
 		c.JSON(http.StatusInternalServerError, model.ErrInternalServerError)
 
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "book created successfully"})
+	h.auditBook(c, model.AuditBookCreate, item.ID)
+	c.Header("ETag", item.ETag())
+	c.JSON(http.StatusOK, gin.H{"message": "book created successfully", "item": item})
 }
 
 func (h *BooksHandler) Find(c *gin.Context) {
-	idStr := c.Param("id")
-	ID, err := strconv.ParseUint(idStr, DecimalBase, BitSize64)
+	ID, err := model.ParseRef(c.Param("id"))
 	if err != nil {
-		log.Println("Find ParseUint err: ", err)
+		logger(c).Info("Find ParseRef", "err", err)
 		c.JSON(http.StatusBadRequest, model.ErrInternalServerError)
 
 		return
 	}
 
-	item, err := h.api.mongo.BooksRepository.Find(ID)
+	item, err := h.api.store.Books().Find(c.Request.Context(), ID)
 	if err != nil {
-		log.Println("Find Find err: ", err)
-		c.JSON(http.StatusInternalServerError, model.ErrInternalServerError)
+		if err == store.ErrNotFound {
+			logger(c).Info("Find Find", "err", err)
+			c.JSON(http.StatusNotFound, model.ErrNotFound)
+		} else {
+			logger(c).Error("Find Find", "err", err)
+			c.JSON(http.StatusInternalServerError, model.ErrInternalServerError)
+		}
 
 		return
 	}
 
+	if notModified(c, item) {
+		return
+	}
+
 	answer := map[string]interface{}{
 		"item": item,
 	}
@@ -100,104 +215,379 @@ func (h *BooksHandler) Find(c *gin.Context) {
 }
 
 func (h *BooksHandler) Update(c *gin.Context) {
-	token := h.api.auth.ExtractToken(c.Request)
-	claims, err := h.api.auth.Validate(token)
+	var item model.Book
+	err := c.ShouldBindJSON(&item)
 	if err != nil {
-		log.Println("Update Validate err: ", err)
-		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+		logger(c).Info("Update ShouldBindJSON", "err", err)
+		c.JSON(http.StatusBadRequest, model.ErrInternalServerError)
 
 		return
 	}
 
-	var item model.Book
-	err = c.ShouldBindJSON(&item)
+	ID, err := model.ParseRef(c.Param("id"))
 	if err != nil {
-		log.Println("Update ShouldBindJSON err: ", err)
+		logger(c).Info("Update ParseRef", "err", err)
 		c.JSON(http.StatusBadRequest, model.ErrInternalServerError)
 
 		return
 	}
 
-	idStr := c.Param("id")
+	existingBook, err := h.api.store.Books().Find(c.Request.Context(), ID)
+	if err != nil {
+		if err == store.ErrNotFound {
+			logger(c).Info("Update Find", "err", err)
+			c.JSON(http.StatusNotFound, model.ErrNotFound)
+		} else {
+			logger(c).Error("Update Find", "err", err)
+			c.JSON(http.StatusInternalServerError, model.ErrInternalServerError)
+		}
+
+		return
+	}
+
+	if !h.api.authorize(c, policy.ActionUpdate, policy.Book{Book: existingBook}) {
+		return
+	}
+	if !ifMatch(c, existingBook) {
+		return
+	}
+
+	item.ID = ID
+	item.Version = existingBook.Version
+	sub, _ := subject(c)
+	keepProtectedFields(sub, &item, existingBook)
+
+	if !h.validate(c, &item) {
+		return
+	}
+
+	item, err = h.api.store.Books().Update(c.Request.Context(), item, sub.ID)
+	if err == store.ErrDuplicate {
+		c.JSON(http.StatusConflict, model.ErrDuplicateISBN)
 
-	ID, err := strconv.ParseUint(idStr, DecimalBase, BitSize64)
+		return
+	}
 	if err != nil {
-		log.Println("Update ParseUint err: ", err)
+		logger(c).Info("Update Update", "err", err)
+		conditionalWriteError(c, err)
+
+		return
+	}
+
+	h.auditBook(c, model.AuditBookUpdate, item.ID)
+	c.Header("ETag", item.ETag())
+	c.JSON(http.StatusOK, gin.H{"message": "book updated successfully", "item": item})
+}
+
+// Patch changes some fields of a book:
+// PATCH /book/:id with application/merge-patch+json or application/json-patch+json
+func (h *BooksHandler) Patch(c *gin.Context) {
+	ID, err := model.ParseRef(c.Param("id"))
+	if err != nil {
+		logger(c).Info("Patch ParseRef", "err", err)
 		c.JSON(http.StatusBadRequest, model.ErrInternalServerError)
 
 		return
 	}
 
-	existingBook, err := h.api.mongo.BooksRepository.Find(ID)
+	body, err := io.ReadAll(c.Request.Body)
 	if err != nil {
-		log.Println("Update Find err: ", err)
-		c.JSON(http.StatusInternalServerError, model.ErrInternalServerError)
+		logger(c).Info("Patch ReadAll", "err", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
 
 		return
 	}
+	patch, err := decodePatch(c.ContentType(), body)
+	if err == errUnsupportedPatch {
+		c.JSON(http.StatusUnsupportedMediaType, model.ErrUnsupportedPatch)
 
-	if existingBook.AuthorID != claims.BaseClaims.ID {
-		log.Println("Update AuthorID err: ", err)
-		c.JSON(http.StatusForbidden, model.ErrForbidden)
+		return
+	}
+	if err != nil {
+		logger(c).Info("Patch decodePatch", "err", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
 
 		return
 	}
 
-	item.ID = ID
-	item.AuthorID = claims.BaseClaims.ID
-	err = h.api.mongo.BooksRepository.Update(item)
+	existingBook, err := h.api.store.Books().Find(c.Request.Context(), ID)
 	if err != nil {
-		log.Println("Update Update err: ", err)
-		c.JSON(http.StatusInternalServerError, model.ErrInternalServerError)
+		if err == store.ErrNotFound {
+			logger(c).Info("Patch Find", "err", err)
+			c.JSON(http.StatusNotFound, model.ErrNotFound)
+		} else {
+			logger(c).Error("Patch Find", "err", err)
+			c.JSON(http.StatusInternalServerError, model.ErrInternalServerError)
+		}
+
+		return
+	}
 
+	if !h.api.authorize(c, policy.ActionUpdate, policy.Book{Book: existingBook}) {
 		return
 	}
+	// Without If-Match the patch applies to whatever version is current,
+	// which is safe as it only touches the fields it names.
+	var version uint64
+	if c.GetHeader("If-Match") != "" {
+		if !ifMatch(c, existingBook) {
+			return
+		}
+		version = existingBook.Version
+	}
+
+	sub, _ := subject(c)
+	item, err := h.api.store.Books().Patch(c.Request.Context(), ID, version, sub.ID, func(current model.Book) (model.Book, error) {
+		if err := policy.Authorize(sub, policy.ActionUpdate, policy.Book{Book: current}); err != nil {
+			return model.Book{}, model.ErrForbidden
+		}
 
-	c.JSON(http.StatusOK, gin.H{"message": "book updated successfully"})
+		item, err := patchBook(current, patch)
+		if err != nil {
+			return model.Book{}, err
+		}
+		keepProtectedFields(sub, &item, current)
+
+		return item, h.check(c.Request.Context(), &item)
+	})
+
+	if err != nil {
+		patchFailed(c, err)
+
+		return
+	}
+
+	h.auditBook(c, model.AuditBookUpdate, item.ID)
+	c.Header("ETag", item.ETag())
+	c.JSON(http.StatusOK, gin.H{"message": "book updated successfully", "item": item})
+}
+
+// patchFailed answers a patch or revert of a book that could not be
+// applied.
+func patchFailed(c *gin.Context, err error) {
+	var validationErr *model.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		c.JSON(http.StatusBadRequest, validationErr)
+	case errors.Is(err, jsonpatch.ErrTestFailed):
+		c.JSON(http.StatusConflict, model.ErrPatchTestFailed)
+	case errors.Is(err, errPatchApply):
+		logger(c).Info("patchFailed patchBook", "err", err)
+		c.JSON(http.StatusUnprocessableEntity, model.ErrPatchNotApplicable)
+	case err == model.ErrForbidden:
+		c.JSON(http.StatusForbidden, model.ErrForbidden)
+	case err == store.ErrDuplicate:
+		c.JSON(http.StatusConflict, model.ErrDuplicateISBN)
+	default:
+		logger(c).Info("patchFailed Patch", "err", err)
+		conditionalWriteError(c, err)
+	}
+}
+
+// keepProtectedFields copies the fields a client may not change from the
+// stored book. Only those allowed to share a book may change who else
+// edits it, and books only go to the trash through Delete.
+func keepProtectedFields(sub policy.Subject, item *model.Book, existing model.Book) {
+	item.OwnerID = existing.OwnerID
+	item.DeletedAt = existing.DeletedAt
+	item.DeletedBy = existing.DeletedBy
+	if policy.Authorize(sub, policy.ActionShare, policy.Book{Book: existing}) != nil {
+		item.CoAuthorIDs = existing.CoAuthorIDs
+		item.LibraryID = existing.LibraryID
+	}
 }
 
 func (h *BooksHandler) Delete(c *gin.Context) {
-	token := h.api.auth.ExtractToken(c.Request)
-	claims, err := h.api.auth.Validate(token)
+	ID, err := model.ParseRef(c.Param("id"))
 	if err != nil {
-		log.Println("Delete Validate err: ", err)
-		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+		logger(c).Info("Delete ParseRef", "err", err)
+		c.JSON(http.StatusBadRequest, model.ErrInternalServerError)
+
+		return
+	}
+
+	existingBook, err := h.api.store.Books().Find(c.Request.Context(), ID)
+	if err != nil {
+		if err == store.ErrNotFound {
+			logger(c).Info("Delete Find", "err", err)
+			c.JSON(http.StatusNotFound, model.ErrNotFound)
+		} else {
+			logger(c).Error("Delete Find", "err", err)
+			c.JSON(http.StatusInternalServerError, model.ErrInternalServerError)
+		}
 
 		return
 	}
 
-	idStr := c.Param("id")
+	if !h.api.authorize(c, policy.ActionDelete, policy.Book{Book: existingBook}) {
+		return
+	}
+	if !ifMatch(c, existingBook) {
+		return
+	}
 
-	ID, err := strconv.ParseUint(idStr, DecimalBase, BitSize64)
+	sub, _ := subject(c)
+	err = h.api.store.Books().Delete(c.Request.Context(), ID, existingBook.Version, sub.ID)
 	if err != nil {
-		log.Println("Delete ParseUint err: ", err)
-		c.JSON(http.StatusBadRequest, model.ErrInternalServerError)
+		logger(c).Info("Delete Delete", "err", err)
+		conditionalWriteError(c, err)
+
+		return
+	}
+
+	h.auditBook(c, model.AuditBookDelete, ID)
+	c.JSON(http.StatusOK, gin.H{"message": "book deleted successfully"})
+}
+
+// Trash lists the deleted books of the caller, or of everyone for admins:
+// GET /trash?offset=&limit=
+func (h *BooksHandler) Trash(c *gin.Context) {
+	sub, ok := subject(c)
+	if !ok {
+		logger(c).Info("Trash empty claims")
+		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return
+	}
+	offset, limit, err := offsetLimit(c)
+	if err != nil {
+		logger(c).Info("Trash offsetLimit", "err", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
 
 		return
 	}
 
-	existingBook, err := h.api.mongo.BooksRepository.Find(ID)
+	ownerID := sub.ID
+	if sub.Role == model.RoleAdmin {
+		ownerID = 0
+	}
+	results, total, err := h.api.store.Books().Trash(c.Request.Context(), ownerID, offset, limit)
 	if err != nil {
-		log.Println("Delete Find err: ", err)
+		logger(c).Error("Trash Trash", "err", err)
 		c.JSON(http.StatusInternalServerError, model.ErrInternalServerError)
 
 		return
 	}
 
-	if existingBook.AuthorID != claims.BaseClaims.ID {
-		log.Println("Delete AuthorID err: ", err)
-		c.JSON(http.StatusForbidden, model.ErrForbidden)
+	answer := map[string]interface{}{
+		"items":  results,
+		"total":  total,
+		"offset": offset,
+		"limit":  limit,
+	}
+
+	c.JSON(http.StatusOK, answer)
+}
+
+// Restore takes a book back out of the trash:
+// POST /book/:id/restore
+func (h *BooksHandler) Restore(c *gin.Context) {
+	ID, err := model.ParseRef(c.Param("id"))
+	if err != nil {
+		logger(c).Info("Restore ParseRef", "err", err)
+		c.JSON(http.StatusBadRequest, model.ErrInternalServerError)
 
 		return
 	}
 
-	err = h.api.mongo.BooksRepository.Delete(ID)
+	deletedBook, err := h.api.store.Books().FindDeleted(c.Request.Context(), ID)
 	if err != nil {
-		log.Println("Delete Delete err: ", err)
-		c.JSON(http.StatusInternalServerError, model.ErrInternalServerError)
+		if err == store.ErrNotFound {
+			logger(c).Info("Restore FindDeleted", "err", err)
+			c.JSON(http.StatusNotFound, model.ErrNotFound)
+		} else {
+			logger(c).Error("Restore FindDeleted", "err", err)
+			c.JSON(http.StatusInternalServerError, model.ErrInternalServerError)
+		}
 
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "book deleted successfully"})
+	if !h.api.authorize(c, policy.ActionRestore, policy.Book{Book: deletedBook}) {
+		return
+	}
+
+	sub, _ := subject(c)
+	item, err := h.api.store.Books().Restore(c.Request.Context(), ID, sub.ID)
+	if err != nil {
+		switch err {
+		case store.ErrNotFound:
+			logger(c).Info("Restore Restore", "err", err)
+			c.JSON(http.StatusNotFound, model.ErrNotFound)
+		case store.ErrDuplicate:
+			logger(c).Info("Restore Restore", "err", err)
+			c.JSON(http.StatusConflict, model.ErrDuplicateISBN)
+		default:
+			logger(c).Error("Restore Restore", "err", err)
+			c.JSON(http.StatusInternalServerError, model.ErrInternalServerError)
+		}
+
+		return
+	}
+
+	h.auditBook(c, model.AuditBookRestore, item.ID)
+	c.Header("ETag", item.ETag())
+	c.JSON(http.StatusOK, gin.H{"message": "book restored successfully", "item": item})
+}
+
+// auditBook records a successful write of a book.
+func (h *BooksHandler) auditBook(c *gin.Context, action model.AuditAction, ID uint64) {
+	h.api.audit(c, model.AuditEvent{
+		Action:  action,
+		Target:  model.AuditTarget(policy.KindBook, ID),
+		Outcome: model.AuditSuccess,
+	})
+}
+
+// validate checks the book, writing the error response if it is invalid.
+func (h *BooksHandler) validate(c *gin.Context, item *model.Book) bool {
+	err := h.check(c.Request.Context(), item)
+	if err == nil {
+		return true
+	}
+
+	var validationErr *model.ValidationError
+	if errors.As(err, &validationErr) {
+		logger(c).Info("validate check", "err", err)
+		c.JSON(http.StatusBadRequest, validationErr)
+	} else {
+		logger(c).Error("validate check", "err", err)
+		c.JSON(http.StatusInternalServerError, model.ErrInternalServerError)
+	}
+
+	return false
+}
+
+// check validates the book fields and that every contributor is a stored
+// author, returning a *model.ValidationError if not.
+func (h *BooksHandler) check(ctx context.Context, item *model.Book) error {
+	if err := item.Validate(); err != nil {
+		return err
+	}
+
+	exist, err := h.api.store.Authors().Exist(ctx, item.ContributorIDs())
+	if err != nil {
+		return err
+	}
+	if !exist {
+		validationErr := model.NewValidationError()
+		validationErr.Add("contributors", "unknown author_id")
+
+		return validationErr
+	}
+
+	return nil
+}
+
+// conditionalWriteError answers a conditional write the store refused, the book
+// may have been changed or deleted after the handler read it.
+func conditionalWriteError(c *gin.Context, err error) {
+	switch err {
+	case store.ErrVersionConflict:
+		c.JSON(http.StatusPreconditionFailed, model.ErrPreconditionFailed)
+	case store.ErrNotFound:
+		c.JSON(http.StatusNotFound, model.ErrNotFound)
+	default:
+		c.JSON(http.StatusInternalServerError, model.ErrInternalServerError)
+	}
 }
@@ -1,39 +1,231 @@
 package http
 
 import (
-	"bookService/auth"
 	"bookService/mocks"
 	"bookService/model"
+	"context"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
 )
 
-func (f *FakeMongoStore) GetAll() ([]model.Book, error) {
-	return []model.Book{}, nil
+func TestFindMissingBook(t *testing.T) {
+	api, memory := newTestAPI(t)
+	book, err := memory.Books().Insert(context.Background(), model.Book{Name: "Dune"}, 1)
+	assert.NoError(t, err)
+
+	for _, ref := range []string{strconv.FormatUint(book.ID, 10), book.PublicID(), book.Slug()} {
+		rr := serveJSON(api, "GET", "/api/v1/book/"+ref, "", nil)
+		assert.Equal(t, http.StatusOK, rr.Code, ref)
+	}
+
+	rr := serveJSON(api, "GET", "/api/v1/book/2", "", nil)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	rr = serveJSON(api, "GET", "/api/v1/book/dune", "", nil)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
 }
 
-func (f *FakeMongoStore) Find(ID uint64) (model.Book, error) {
-	return model.Book{}, nil
+func TestBookConditionalRequests(t *testing.T) {
+	api, _ := newTestAPI(t)
+	token := signIn(t, api, "writer@example.com")
+
+	rr := serveJSON(api, "POST", "/api/v1/book", token, model.Book{Name: "Dune"})
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var created struct {
+		Item model.Book `json:"item"`
+	}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &created))
+	path := "/api/v1/book/" + created.Item.PublicID()
+	etag := rr.Header().Get("ETag")
+	assert.Equal(t, `"1"`, etag)
+
+	rr = serveJSON(api, "GET", path, "", nil, "If-None-Match", etag)
+	assert.Equal(t, http.StatusNotModified, rr.Code)
+
+	rr = serveJSON(api, "PUT", path, token, model.Book{Name: "Dune Messiah"})
+	assert.Equal(t, http.StatusPreconditionRequired, rr.Code)
+
+	rr = serveJSON(api, "PUT", path, token, model.Book{Name: "Dune Messiah"}, "If-Match", etag)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `"2"`, rr.Header().Get("ETag"))
+
+	rr = serveJSON(api, "PUT", path, token, model.Book{Name: "Children of Dune"}, "If-Match", etag)
+	assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
+
+	rr = serveJSON(api, "GET", path, "", nil, "If-None-Match", etag)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `"2"`, rr.Header().Get("ETag"))
+
+	rr = serveJSON(api, "DELETE", path, token, nil, "If-Match", etag)
+	assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
+
+	rr = serveJSON(api, "DELETE", path, token, nil, "If-Match", `"2"`)
+	assert.Equal(t, http.StatusOK, rr.Code)
 }
 
-func (f *FakeMongoStore) Update(book model.Book) error {
-	return nil
+func TestPatchBook(t *testing.T) {
+	api, memory := newTestAPI(t)
+	token := signIn(t, api, "writer@example.com")
+	writer, err := memory.Users().GetByLogin(context.Background(), "writer@example.com")
+	assert.NoError(t, err)
+	book, err := memory.Books().Insert(context.Background(),
+		model.Book{Name: "Dune", Language: "en", Genres: []string{"science fiction"}}, writer.ID)
+	assert.NoError(t, err)
+	path := "/api/v1/book/" + book.PublicID()
+
+	rr := serveJSON(api, "PATCH", path, token, map[string]interface{}{"subtitle": "Book One"})
+	assert.Equal(t, http.StatusUnsupportedMediaType, rr.Code)
+
+	merge := map[string]interface{}{"subtitle": "Book One", "language": nil}
+	rr = serveJSON(api, "PATCH", path, token, merge, "Content-Type", "application/merge-patch+json")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `"2"`, rr.Header().Get("ETag"))
+
+	patched, err := memory.Books().Find(context.Background(), book.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Dune", patched.Name)
+	assert.Equal(t, "Book One", patched.Subtitle)
+	assert.Empty(t, patched.Language)
+	assert.Equal(t, writer.ID, patched.OwnerID)
+
+	ops := []map[string]interface{}{
+		{"op": "test", "path": "/name", "value": "Dune"},
+		{"op": "add", "path": "/genres/-", "value": "Adventure"},
+	}
+	rr = serveJSON(api, "PATCH", path, token, ops, "Content-Type", "application/json-patch+json")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	patched, _ = memory.Books().Find(context.Background(), book.ID)
+	assert.Equal(t, []string{"science fiction", "adventure"}, patched.Genres)
+
+	ops = []map[string]interface{}{{"op": "test", "path": "/name", "value": "Emma"}}
+	rr = serveJSON(api, "PATCH", path, token, ops, "Content-Type", "application/json-patch+json")
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	ops = []map[string]interface{}{{"op": "remove", "path": "/publisher"}}
+	rr = serveJSON(api, "PATCH", path, token, ops, "Content-Type", "application/json-patch+json")
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+
+	rr = serveJSON(api, "PATCH", path, token, map[string]interface{}{"name": ""},
+		"Content-Type", "application/merge-patch+json")
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr = serveJSON(api, "PATCH", path, token, map[string]interface{}{"subtitel": "Book Two"},
+		"Content-Type", "application/merge-patch+json")
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	ops = []map[string]interface{}{{"op": "add", "path": "/subtitel", "value": "Book Two"}}
+	rr = serveJSON(api, "PATCH", path, token, ops, "Content-Type", "application/json-patch+json")
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	patched, _ = memory.Books().Find(context.Background(), book.ID)
+	assert.Equal(t, "Book One", patched.Subtitle)
+
+	rr = serveJSON(api, "PATCH", path, token, map[string]interface{}{"subtitle": "Stale"},
+		"Content-Type", "application/merge-patch+json", "If-Match", `"1"`)
+	assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
 }
 
-func (f *FakeMongoStore) Delete(ID uint64) error {
-	return nil
+func TestDeleteMovesBookToTrash(t *testing.T) {
+	api, _ := newTestAPI(t)
+	token := signIn(t, api, "writer@example.com")
+	otherToken := signIn(t, api, "reader@example.com")
+
+	rr := serveJSON(api, "POST", "/api/v1/book", token, model.Book{Name: "Dune"})
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var created struct {
+		Item model.Book `json:"item"`
+	}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &created))
+	path := "/api/v1/book/" + created.Item.PublicID()
+
+	rr = serveJSON(api, "DELETE", path, token, nil, "If-Match", created.Item.ETag())
+	assert.Equal(t, http.StatusOK, rr.Code)
+	rr = serveJSON(api, "GET", path, "", nil)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	var trash struct {
+		Items []model.Book `json:"items"`
+		Total int          `json:"total"`
+	}
+	rr = serveJSON(api, "GET", "/api/v1/trash", token, nil)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &trash))
+	assert.Equal(t, 1, trash.Total)
+	assert.NotNil(t, trash.Items[0].DeletedAt)
+
+	rr = serveJSON(api, "GET", "/api/v1/trash", otherToken, nil)
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &trash))
+	assert.Equal(t, 0, trash.Total)
+
+	rr = serveJSON(api, "POST", path+"/restore", otherToken, nil)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	rr = serveJSON(api, "POST", path+"/restore", token, nil)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	rr = serveJSON(api, "GET", path, "", nil)
+	assert.Equal(t, http.StatusOK, rr.Code)
 }
 
-func (f *FakeJWTAuth) ExtractToken(req *http.Request) string {
-	return "fake_token"
+func TestTrashedBookISBNCanBeAddedAgain(t *testing.T) {
+	api, _ := newTestAPI(t)
+	token := signIn(t, api, "writer@example.com")
+
+	rr := serveJSON(api, "POST", "/api/v1/book", token, model.Book{Name: "Dune", ISBN: "9780441013593"})
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var created struct {
+		Item model.Book `json:"item"`
+	}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &created))
+	path := "/api/v1/book/" + created.Item.PublicID()
+	rr = serveJSON(api, "DELETE", path, token, nil, "If-Match", created.Item.ETag())
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	rr = serveJSON(api, "POST", "/api/v1/book", token, model.Book{Name: "Dune", ISBN: "0-441-01359-7"})
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	rr = serveJSON(api, "POST", path+"/restore", token, nil)
+	assert.Equal(t, http.StatusConflict, rr.Code)
 }
 
-func (f *FakeJWTAuth) Validate(token string) (*auth.AccessClaims, error) {
-	return &auth.AccessClaims{BaseClaims: auth.BaseClaims{ID: 123}}, nil
+func TestUpdateCannotTrashBook(t *testing.T) {
+	api, memory := newTestAPI(t)
+	signIn(t, api, "writer@example.com")
+	coToken := signIn(t, api, "coauthor@example.com")
+	writer, _ := memory.Users().GetByLogin(context.Background(), "writer@example.com")
+	coAuthor, _ := memory.Users().GetByLogin(context.Background(), "coauthor@example.com")
+	book, err := memory.Books().Insert(context.Background(),
+		model.Book{Name: "Dune", CoAuthorIDs: []uint64{coAuthor.ID}}, writer.ID)
+	assert.NoError(t, err)
+	path := "/api/v1/book/" + book.PublicID()
+
+	rr := serveJSON(api, "DELETE", path, coToken, nil, "If-Match", book.ETag())
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	merge := map[string]interface{}{"deleted_at": "2000-01-01T00:00:00Z", "deleted_by": coAuthor.ID}
+	rr = serveJSON(api, "PATCH", path, coToken, merge, "Content-Type", "application/merge-patch+json")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	rr = serveJSON(api, "GET", path, "", nil)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	deletedAt := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	put := model.Book{Name: "Dune", CoAuthorIDs: []uint64{coAuthor.ID}, DeletedAt: &deletedAt, DeletedBy: coAuthor.ID}
+	rr = serveJSON(api, "PUT", path, coToken, put, "If-Match", `"2"`)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	rr = serveJSON(api, "GET", path, "", nil)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	stored, err := memory.Books().Find(context.Background(), book.ID)
+	assert.NoError(t, err)
+	assert.Nil(t, stored.DeletedAt)
+	assert.Zero(t, stored.DeletedBy)
+	n, err := memory.Books().Purge(context.Background(), time.Now())
+	assert.NoError(t, err)
+	assert.Zero(t, n)
 }
 
 func TestGetAllHandler(t *testing.T) {
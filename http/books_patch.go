@@ -0,0 +1,80 @@
+package http
+
+import (
+	"bookService/model"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+)
+
+const (
+	contentTypeMergePatch = "application/merge-patch+json"
+	contentTypeJSONPatch  = "application/json-patch+json"
+)
+
+var (
+	errUnsupportedPatch = errors.New("unsupported patch media type")
+	// errPatchApply wraps the errors of a well formed patch that does not
+	// fit the book, such as a path that does not exist.
+	errPatchApply = errors.New("patch cannot be applied")
+)
+
+// documentPatch transforms the JSON form of a document.
+type documentPatch func(doc []byte) ([]byte, error)
+
+// decodePatch parses a JSON Merge Patch (RFC 7396) or a JSON Patch
+// (RFC 6902), depending on the content type.
+func decodePatch(contentType string, body []byte) (documentPatch, error) {
+	switch contentType {
+	case contentTypeMergePatch:
+		if !json.Valid(body) {
+			return nil, jsonpatch.ErrBadJSONPatch
+		}
+
+		return func(doc []byte) ([]byte, error) {
+			return jsonpatch.MergePatch(doc, body)
+		}, nil
+	case contentTypeJSONPatch:
+		patch, err := jsonpatch.DecodePatch(body)
+		if err != nil {
+			return nil, err
+		}
+
+		return patch.Apply, nil
+	}
+
+	return nil, errUnsupportedPatch
+}
+
+// patchBook applies patch to the JSON form of the book. A patched document
+// with fields a book does not have is not applicable, rather than having
+// them silently dropped. The caller restores the fields clients may not
+// change.
+func patchBook(book model.Book, patch documentPatch) (model.Book, error) {
+	doc, err := json.Marshal(book)
+	if err != nil {
+		return model.Book{}, err
+	}
+
+	doc, err = patch(doc)
+	if err != nil {
+		return model.Book{}, fmt.Errorf("%w: %w", errPatchApply, err)
+	}
+
+	// The fields MarshalJSON adds are accepted and ignored.
+	var patched struct {
+		model.Book
+		PublicID string `json:"public_id"`
+		Slug     string `json:"slug"`
+	}
+	decoder := json.NewDecoder(bytes.NewReader(doc))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&patched); err != nil {
+		return model.Book{}, fmt.Errorf("%w: %w", errPatchApply, err)
+	}
+
+	return patched.Book, nil
+}
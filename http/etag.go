@@ -0,0 +1,56 @@
+package http
+
+import (
+	"bookService/model"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// notModified answers a GET with 304 if the client already holds the
+// current version of the book.
+func notModified(c *gin.Context, book model.Book) bool {
+	c.Header("ETag", book.ETag())
+	if !matchesETag(c.GetHeader("If-None-Match"), book.ETag(), true) {
+		return false
+	}
+	c.Status(http.StatusNotModified)
+
+	return true
+}
+
+// ifMatch checks that a write is based on the current version of the book,
+// writing 428 if the client sent no If-Match and 412 if it is stale.
+func ifMatch(c *gin.Context, book model.Book) bool {
+	header := c.GetHeader("If-Match")
+	if header == "" {
+		c.JSON(http.StatusPreconditionRequired, model.ErrMissingIfMatch)
+
+		return false
+	}
+	if !matchesETag(header, book.ETag(), false) {
+		c.JSON(http.StatusPreconditionFailed, model.ErrPreconditionFailed)
+
+		return false
+	}
+
+	return true
+}
+
+// matchesETag reports whether etag is listed in an If-Match or
+// If-None-Match header. If-Match requires a strong match, so weak tags
+// only count if weak is set.
+func matchesETag(header, etag string, weak bool) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if weak {
+			tag = strings.TrimPrefix(tag, "W/")
+		}
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+
+	return false
+}
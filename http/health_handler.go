@@ -0,0 +1,57 @@
+package http
+
+import (
+	"bookService/health"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// readinessTimeout bounds each readiness check.
+const readinessTimeout = 2 * time.Second
+
+type HealthHandlerInterface interface {
+	Live(c *gin.Context)
+	Ready(c *gin.Context)
+}
+
+// HealthHandler serves the probes of the orchestrator.
+type HealthHandler struct {
+	api *api
+}
+
+func NewHealthHandler(a *api) *HealthHandler {
+	return &HealthHandler{
+		api: a,
+	}
+}
+
+// Live answers as long as the process serves requests:
+// GET /healthz
+func (h *HealthHandler) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": health.StatusOK})
+}
+
+// Ready reports whether the store and the signing keys are usable, with
+// the result of every check. It fails once shutdown started:
+// GET /readyz
+func (h *HealthHandler) Ready(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	if h.api.draining.Load() {
+		c.JSON(http.StatusServiceUnavailable, health.Report{Status: health.StatusDraining, Checks: []health.Result{}})
+
+		return
+	}
+
+	checks := append(h.api.store.Checks(), h.api.auth.Checks()...)
+	report := health.Run(c.Request.Context(), readinessTimeout, checks)
+	if report.Status != health.StatusOK {
+		logger(c).Warn("Ready failing", "checks", report.Checks)
+		c.JSON(http.StatusServiceUnavailable, report)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,56 @@
+package http
+
+import (
+	"bookService/health"
+	"bookService/store"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// unreadyStore fails its readiness check.
+type unreadyStore struct {
+	store.Store
+}
+
+func (unreadyStore) Checks() []health.Check {
+	return []health.Check{{Name: "mongo", Probe: func(context.Context) error {
+		return errors.New("no reachable servers")
+	}}}
+}
+
+func TestHealthProbes(t *testing.T) {
+	api, memory := newTestAPI(t)
+
+	rr := serveJSON(api, "GET", "/healthz", "", nil)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var report health.Report
+	rr = serveJSON(api, "GET", "/readyz", "", nil)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &report))
+	assert.Equal(t, health.StatusOK, report.Status)
+	assert.Equal(t, "signing_keys", report.Checks[0].Name)
+
+	api.store = unreadyStore{memory}
+	rr = serveJSON(api, "GET", "/readyz", "", nil)
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &report))
+	assert.Equal(t, health.StatusFailing, report.Status)
+	assert.Equal(t, "no reachable servers", report.Checks[0].Error)
+	assert.Equal(t, health.StatusOK, report.Checks[1].Status)
+
+	api.store = memory
+	api.draining.Store(true)
+	rr = serveJSON(api, "GET", "/readyz", "", nil)
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &report))
+	assert.Equal(t, health.StatusDraining, report.Status)
+
+	rr = serveJSON(api, "GET", "/healthz", "", nil)
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
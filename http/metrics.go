@@ -0,0 +1,48 @@
+package http
+
+import (
+	"bookService/metrics"
+	"bookService/model"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// instrument counts and times requests by route pattern, so that ids in
+// paths do not make a series per book.
+func instrument() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		status := strconv.Itoa(c.Writer.Status())
+		metrics.HTTPRequests.WithLabelValues(route, c.Request.Method, status).Inc()
+		metrics.HTTPDuration.WithLabelValues(route, c.Request.Method, status).Observe(time.Since(start).Seconds())
+	}
+}
+
+// metricsHandler serves metrics.Registry in the Prometheus text format.
+func metricsHandler() gin.HandlerFunc {
+	return gin.WrapH(promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{}))
+}
+
+// countAuth counts sign ins and token refreshes by outcome.
+func countAuth(action model.AuditAction, outcome model.AuditOutcome) {
+	result := metrics.OutcomeFailure
+	if outcome == model.AuditSuccess {
+		result = metrics.OutcomeSuccess
+	}
+
+	switch action {
+	case model.AuditSignIn:
+		metrics.SignIns.WithLabelValues(result).Inc()
+	case model.AuditRefresh:
+		metrics.TokenRefreshes.WithLabelValues(result).Inc()
+	}
+}
@@ -0,0 +1,97 @@
+package http
+
+import (
+	"bookService/logging"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	headerRequestID = "X-Request-ID"
+	requestIDKey    = "requestID"
+	// maxRequestIDLength bounds the ids taken from clients, longer ones are
+	// replaced.
+	maxRequestIDLength = 128
+)
+
+// requestContext takes the request id from X-Request-ID or assigns one,
+// echoes it in the response and puts a logger tagged with it into the
+// request context. Once the request is answered it logs the outcome.
+func (a *api) requestContext() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		ID := c.GetHeader(headerRequestID)
+		if !validRequestID(ID) {
+			ID = newRequestID()
+		}
+		c.Set(requestIDKey, ID)
+		c.Header(headerRequestID, ID)
+
+		base := a.log
+		if base == nil {
+			base = slog.Default()
+		}
+		log := base.With("request_id", ID)
+		c.Request = c.Request.WithContext(logging.NewContext(c.Request.Context(), log))
+
+		c.Next()
+
+		// The route pattern rather than the path, so that tokens in the
+		// path such as the password recovery one stay out of the log.
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		level := slog.LevelInfo
+		if c.Writer.Status() >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		// Later middleware may have tagged the logger further.
+		logger(c).Log(c.Request.Context(), level, "request",
+			"method", c.Request.Method,
+			"route", route,
+			"status", c.Writer.Status(),
+			"duration", time.Since(start),
+			"ip", c.ClientIP(),
+		)
+	}
+}
+
+// logger returns the logger of the request.
+func logger(c *gin.Context) *slog.Logger {
+	return logging.FromContext(c.Request.Context())
+}
+
+// requestID returns the id requestContext assigned to the request.
+func requestID(c *gin.Context) string {
+	return c.GetString(requestIDKey)
+}
+
+func validRequestID(ID string) bool {
+	if ID == "" || len(ID) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range ID {
+		// Printable ASCII without spaces, so that ids cannot break up log
+		// lines or headers.
+		if r <= ' ' || r > '~' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func newRequestID() string {
+	var buf [16]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		slog.Error("newRequestID Read", "err", err)
+	}
+
+	return hex.EncodeToString(buf[:])
+}
@@ -0,0 +1,70 @@
+package http
+
+import (
+	"bookService/logging"
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestContext(t *testing.T) {
+	api, _ := newTestAPI(t)
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, logging.FormatText, "info")
+	assert.NoError(t, err)
+	api.log = logger
+
+	rr := serveJSON(api, "GET", "/api/v1/book/2", "", nil, "X-Request-ID", "req-42")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Equal(t, "req-42", rr.Header().Get("X-Request-ID"))
+	assert.Contains(t, buf.String(), "request_id=req-42")
+	assert.Contains(t, buf.String(), "route=/api/v1/book/:id")
+
+	rr = serveJSON(api, "GET", "/api/v1/books", "", nil, "X-Request-ID", "bad id\n")
+	generated := rr.Header().Get("X-Request-ID")
+	assert.Len(t, generated, 32)
+
+	buf.Reset()
+	serveJSON(api, "POST", "/api/v1/setNewPassword/0123456789abcdef", "", map[string]string{"password": "x"})
+	assert.NotContains(t, buf.String(), "0123456789abcdef")
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		assert.Contains(t, line, "request_id=")
+	}
+}
+
+func TestInvalidBookIsNotLoggedAsError(t *testing.T) {
+	api, _ := newTestAPI(t)
+	token := signIn(t, api, "writer@example.com")
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, logging.FormatText, "info")
+	assert.NoError(t, err)
+	api.log = logger
+
+	rr := serveJSON(api, "POST", "/api/v1/book", token, map[string]string{"name": ""})
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, buf.String(), "validate check")
+	assert.NotContains(t, buf.String(), "level=ERROR")
+}
+
+func TestTokenFailuresCarryRequestID(t *testing.T) {
+	api, _ := newTestAPI(t)
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, logging.FormatText, "info")
+	assert.NoError(t, err)
+	api.log = logger
+
+	rr := serveJSON(api, "GET", "/api/v1/sessions", "not-a-token", nil, "X-Request-ID", "req-7")
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	rr = refresh(api, "not-a-token")
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	for _, msg := range []string{"msg=\"Validate ParseWithClaims\"", "msg=\"Refresh ParseWithClaims\""} {
+		assert.Contains(t, buf.String(), msg)
+	}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		assert.Contains(t, line, "request_id=")
+	}
+}
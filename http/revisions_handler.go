@@ -0,0 +1,213 @@
+package http
+
+import (
+	"bookService/model"
+	"bookService/policy"
+	"bookService/store"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RevisionsHandlerInterface interface {
+	GetAll(c *gin.Context)
+	Diff(c *gin.Context)
+	Revert(c *gin.Context)
+}
+
+// RevisionsHandler serves the history of a book to those who may edit it.
+type RevisionsHandler struct {
+	api *api
+}
+
+func NewRevisionsHandler(a *api) *RevisionsHandler {
+	return &RevisionsHandler{
+		api: a,
+	}
+}
+
+// GetAll lists the revisions of a book, the newest first:
+// GET /book/:id/revisions?offset=&limit=
+func (h *RevisionsHandler) GetAll(c *gin.Context) {
+	book, ok := h.book(c, true)
+	if !ok {
+		return
+	}
+	offset, limit, err := offsetLimit(c)
+	if err != nil {
+		logger(c).Info("GetAll offsetLimit", "err", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	results, total, err := h.api.store.Revisions().List(c.Request.Context(), book.ID, offset, limit)
+	if err != nil {
+		logger(c).Error("GetAll List", "err", err)
+		c.JSON(http.StatusInternalServerError, model.ErrInternalServerError)
+
+		return
+	}
+
+	answer := map[string]interface{}{
+		"items":  results,
+		"total":  total,
+		"offset": offset,
+		"limit":  limit,
+	}
+
+	c.JSON(http.StatusOK, answer)
+}
+
+// Diff shows what a revision changed, compared with the revision before it
+// or the one given by against:
+// GET /book/:id/revisions/:rev/diff?against=
+func (h *RevisionsHandler) Diff(c *gin.Context) {
+	book, ok := h.book(c, true)
+	if !ok {
+		return
+	}
+	revision, ok := h.revision(c, book.ID, c.Param("rev"))
+	if !ok {
+		return
+	}
+
+	// The first revision, or one whose predecessor predates the history,
+	// is compared with an empty book.
+	var from model.BookRevision
+	if against := c.Query("against"); against != "" {
+		if from, ok = h.revision(c, book.ID, against); !ok {
+			return
+		}
+	} else if revision.Revision > 1 {
+		previous, err := h.api.store.Revisions().Find(c.Request.Context(), book.ID, revision.Revision-1)
+		if err != nil && err != store.ErrNotFound {
+			logger(c).Error("Diff Find", "err", err)
+			c.JSON(http.StatusInternalServerError, model.ErrInternalServerError)
+
+			return
+		}
+		from = previous
+	}
+
+	answer := map[string]interface{}{
+		"from":    from.Revision,
+		"to":      revision.Revision,
+		"changes": model.DiffBooks(from.Snapshot, revision.Snapshot),
+	}
+
+	c.JSON(http.StatusOK, answer)
+}
+
+// Revert sets the book back to the state of a revision, as a new revision.
+// Like PATCH it applies to the current version unless If-Match is given:
+// POST /book/:id/revisions/:rev/revert
+func (h *RevisionsHandler) Revert(c *gin.Context) {
+	book, ok := h.book(c, false)
+	if !ok {
+		return
+	}
+	revision, ok := h.revision(c, book.ID, c.Param("rev"))
+	if !ok {
+		return
+	}
+
+	var version uint64
+	if c.GetHeader("If-Match") != "" {
+		if !ifMatch(c, book) {
+			return
+		}
+		version = book.Version
+	}
+
+	sub, _ := subject(c)
+	item, err := h.api.store.Books().Patch(c.Request.Context(), book.ID, version, sub.ID, func(current model.Book) (model.Book, error) {
+		if err := policy.Authorize(sub, policy.ActionUpdate, policy.Book{Book: current}); err != nil {
+			return model.Book{}, model.ErrForbidden
+		}
+
+		item := revision.Snapshot
+		// Reverting to a deleted state would move the book to the trash
+		// without a delete.
+		item.DeletedAt = nil
+		item.DeletedBy = 0
+		keepProtectedFields(sub, &item, current)
+
+		return item, h.api.Books().check(c.Request.Context(), &item)
+	})
+	if err != nil {
+		patchFailed(c, err)
+
+		return
+	}
+
+	h.api.audit(c, model.AuditEvent{
+		Action:  model.AuditBookRevert,
+		Target:  model.AuditTarget(policy.KindBook, item.ID),
+		Outcome: model.AuditSuccess,
+		Reason:  "revision " + strconv.FormatUint(revision.Revision, DecimalBase),
+	})
+	c.Header("ETag", item.ETag())
+	c.JSON(http.StatusOK, gin.H{"message": "book reverted successfully", "item": item})
+}
+
+// book loads the book of the request and checks that the caller may edit
+// it, writing the error response if not. Reading the history of a book in
+// the trash is allowed if withDeleted is set.
+func (h *RevisionsHandler) book(c *gin.Context, withDeleted bool) (model.Book, bool) {
+	ID, err := model.ParseRef(c.Param("id"))
+	if err != nil {
+		logger(c).Info("book ParseRef", "err", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return model.Book{}, false
+	}
+
+	book, err := h.api.store.Books().Find(c.Request.Context(), ID)
+	if err == store.ErrNotFound && withDeleted {
+		book, err = h.api.store.Books().FindDeleted(c.Request.Context(), ID)
+	}
+	if err != nil {
+		if err == store.ErrNotFound {
+			logger(c).Info("book Find", "err", err)
+			c.JSON(http.StatusNotFound, model.ErrNotFound)
+		} else {
+			logger(c).Error("book Find", "err", err)
+			c.JSON(http.StatusInternalServerError, model.ErrInternalServerError)
+		}
+
+		return model.Book{}, false
+	}
+
+	if !h.api.authorize(c, policy.ActionUpdate, policy.Book{Book: book}) {
+		return model.Book{}, false
+	}
+
+	return book, true
+}
+
+func (h *RevisionsHandler) revision(c *gin.Context, bookID uint64, param string) (model.BookRevision, bool) {
+	number, err := strconv.ParseUint(param, DecimalBase, BitSize64)
+	if err != nil {
+		logger(c).Info("revision ParseUint", "err", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return model.BookRevision{}, false
+	}
+
+	revision, err := h.api.store.Revisions().Find(c.Request.Context(), bookID, number)
+	if err != nil {
+		if err == store.ErrNotFound {
+			logger(c).Info("revision Find", "err", err)
+			c.JSON(http.StatusNotFound, model.ErrNotFound)
+		} else {
+			logger(c).Error("revision Find", "err", err)
+			c.JSON(http.StatusInternalServerError, model.ErrInternalServerError)
+		}
+
+		return model.BookRevision{}, false
+	}
+
+	return revision, true
+}
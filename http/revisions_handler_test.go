@@ -0,0 +1,68 @@
+package http
+
+import (
+	"bookService/model"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBookRevisions(t *testing.T) {
+	api, _ := newTestAPI(t)
+	token := signIn(t, api, "writer@example.com")
+	otherToken := signIn(t, api, "reader@example.com")
+
+	rr := serveJSON(api, "POST", "/api/v1/book", token, model.Book{Name: "Dune", Language: "en"})
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var created struct {
+		Item model.Book `json:"item"`
+	}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &created))
+	path := "/api/v1/book/" + created.Item.PublicID()
+
+	rr = serveJSON(api, "PATCH", path, token, map[string]interface{}{"name": "Dune Messiah"},
+		"Content-Type", "application/merge-patch+json")
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var revisions struct {
+		Items []model.BookRevision `json:"items"`
+		Total int                  `json:"total"`
+	}
+	rr = serveJSON(api, "GET", path+"/revisions", token, nil)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &revisions))
+	assert.Equal(t, 2, revisions.Total)
+	assert.Equal(t, model.RevisionUpdate, revisions.Items[0].Action)
+	assert.Equal(t, []string{"name"}, revisions.Items[0].Changed)
+	assert.Equal(t, model.RevisionCreate, revisions.Items[1].Action)
+
+	rr = serveJSON(api, "GET", path+"/revisions", otherToken, nil)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	var diff struct {
+		From    uint64              `json:"from"`
+		To      uint64              `json:"to"`
+		Changes []model.FieldChange `json:"changes"`
+	}
+	rr = serveJSON(api, "GET", path+"/revisions/2/diff", token, nil)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &diff))
+	assert.Equal(t, uint64(1), diff.From)
+	assert.Equal(t, []model.FieldChange{{Field: "name", From: "Dune", To: "Dune Messiah"}}, diff.Changes)
+
+	rr = serveJSON(api, "POST", path+"/revisions/1/revert", token, nil)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `"3"`, rr.Header().Get("ETag"))
+
+	rr = serveJSON(api, "GET", path, "", nil)
+	var found struct {
+		Item model.Book `json:"item"`
+	}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &found))
+	assert.Equal(t, "Dune", found.Item.Name)
+
+	rr = serveJSON(api, "POST", path+"/revisions/9/revert", token, nil)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
@@ -1,16 +1,24 @@
 package http
 
 import (
+	"bookService/config"
+	"bookService/model"
 	"errors"
-	"log"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
 func configureRouter(api *api) *gin.Engine {
-	router := gin.Default()
-	router.Use(CORSMiddleware())
+	router := gin.New()
+	router.Use(api.requestContext(), traceRequests(), instrument(), gin.Recovery(), CORSMiddleware(api.conf.CORS))
+
+	router.GET("/.well-known/jwks.json", api.Auth().JWKS)
+	router.GET("/metrics", metricsHandler())
+	router.GET("/healthz", api.Health().Live)
+	router.GET("/readyz", api.Health().Ready)
 
 	public := router.Group("api/v1")
 
@@ -22,25 +30,78 @@ func configureRouter(api *api) *gin.Engine {
 
 	public.GET("/books", api.Books().GetAll)
 	public.GET("/book/:id", api.Books().Find)
-	public.POST("/book", api.Books().Add)
-	public.PUT("/book/:id", api.Books().Update)
-	public.DELETE("/book/:id", api.Books().Delete)
+	public.GET("/authors", api.Authors().GetAll)
+	public.GET("/author/:id", api.Authors().Find)
+
+	private := public.Group("", api.auth.Authorize)
+	private.POST("/logout", api.Auth().Logout)
+	private.POST("/logout/all", api.Auth().LogoutAll)
+	private.GET("/sessions", api.Sessions().GetAll)
+	private.DELETE("/session/:id", api.Sessions().Delete)
+
+	private.POST("/book", api.Books().Add)
+	private.PUT("/book/:id", api.Books().Update)
+	private.PATCH("/book/:id", api.Books().Patch)
+	private.DELETE("/book/:id", api.Books().Delete)
+	private.POST("/book/:id/restore", api.Books().Restore)
+	private.GET("/trash", api.Books().Trash)
+	private.GET("/book/:id/revisions", api.Revisions().GetAll)
+	private.GET("/book/:id/revisions/:rev/diff", api.Revisions().Diff)
+	private.POST("/book/:id/revisions/:rev/revert", api.Revisions().Revert)
+	private.POST("/author", api.Authors().Add)
+	private.PUT("/author/:id", api.Authors().Update)
+	private.DELETE("/author/:id", api.Authors().Delete)
+
+	admin := private.Group("", api.auth.RequireRole(model.RoleAdmin))
+	admin.GET("/users", api.Users().GetAll)
+	admin.GET("/user/:id", api.Users().Find)
+	admin.PUT("/user/:id/role", api.Users().SetRole)
+	admin.POST("/user/:id/lock", api.Users().Lock)
+	admin.POST("/user/:id/unlock", api.Users().Unlock)
+	admin.POST("/user/:id/resetPassword", api.Users().ResetPassword)
+	admin.DELETE("/user/:id", api.Users().Delete)
+	admin.GET("/audit", api.Audit().GetAll)
+	admin.GET("/audit/export", api.Audit().Export)
 
 	router.NoRoute(func(c *gin.Context) {
-		log.Println("route not found")
+		logger(c).Info("route not found")
 		c.JSON(http.StatusNotFound, errors.New("record not found"))
 	})
 
 	return router
 }
 
-func CORSMiddleware() gin.HandlerFunc {
+// CORSMiddleware sets the CORS headers of the policy in conf and answers
+// preflight requests.
+func CORSMiddleware(conf config.CORSConfig) gin.HandlerFunc {
+	allowed := make(map[string]bool, len(conf.AllowedOrigins))
+	for _, origin := range conf.AllowedOrigins {
+		allowed[origin] = true
+	}
+	methods := strings.Join(conf.AllowedMethods, ", ")
+	headers := strings.Join(conf.AllowedHeaders, ", ")
+	exposed := strings.Join(conf.ExposedHeaders, ", ")
+	maxAge := strconv.Itoa(int(conf.MaxAge.Seconds()))
+
 	return func(c *gin.Context) {
-		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding,"+
-			"X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
+		header := c.Writer.Header()
+		if allowed["*"] {
+			header.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			header.Add("Vary", "Origin")
+			if origin := c.GetHeader("Origin"); allowed[origin] {
+				header.Set("Access-Control-Allow-Origin", origin)
+			}
+		}
+		if conf.AllowCredentials {
+			header.Set("Access-Control-Allow-Credentials", "true")
+		}
+		header.Set("Access-Control-Allow-Headers", headers)
+		header.Set("Access-Control-Expose-Headers", exposed)
+		header.Set("Access-Control-Allow-Methods", methods)
+		if conf.MaxAge > 0 {
+			header.Set("Access-Control-Max-Age", maxAge)
+		}
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(http.StatusNoContent)
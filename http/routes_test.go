@@ -0,0 +1,35 @@
+package http
+
+import (
+	"bookService/config"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCORSMiddleware(t *testing.T) {
+	api, _ := newTestAPI(t)
+
+	rr := serveJSON(api, "OPTIONS", "/api/v1/books", "", nil, "Origin", "https://anywhere.example.com")
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Equal(t, "*", rr.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, rr.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Contains(t, rr.Header().Get("Access-Control-Allow-Headers"), "If-Match")
+
+	api.conf = config.Default()
+	api.conf.CORS.AllowedOrigins = []string{"https://books.example.com"}
+	api.conf.CORS.AllowCredentials = true
+	api.conf.CORS.MaxAge = time.Hour
+	api.router = configureRouter(api)
+
+	rr = serveJSON(api, "OPTIONS", "/api/v1/books", "", nil, "Origin", "https://books.example.com")
+	assert.Equal(t, "https://books.example.com", rr.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "Origin", rr.Header().Get("Vary"))
+	assert.Equal(t, "true", rr.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Equal(t, "3600", rr.Header().Get("Access-Control-Max-Age"))
+
+	rr = serveJSON(api, "OPTIONS", "/api/v1/books", "", nil, "Origin", "https://evil.example.com")
+	assert.Empty(t, rr.Header().Get("Access-Control-Allow-Origin"))
+}
@@ -2,53 +2,150 @@ package http
 
 import (
 	"bookService/auth"
+	"bookService/config"
 	"bookService/store"
-	"log"
+	"context"
+	"errors"
+	"log/slog"
+	"net"
 	"net/http"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
-var wg sync.WaitGroup
-
 type api struct {
-	mongo  *store.MongoStore
+	conf   *config.Config
+	store  store.Store
 	router *gin.Engine
 	auth   auth.Middleware
+	log    *slog.Logger
+	// draining is set once shutdown started, readiness fails from then on.
+	draining atomic.Bool
+	// tasks are the goroutines handlers leave running after responding.
+	tasks tasks
+
+	booksHandler     *BooksHandler
+	revisionsHandler *RevisionsHandler
+	authHandler      *AuthHandler
+	sessionsHandler  *SessionsHandler
+	usersHandler     *UsersHandler
+	authorsHandler   *AuthorsHandler
+	auditHandler     *AuditHandler
+	healthHandler    *HealthHandler
+}
 
-	booksHandler *BooksHandler
-	authHandler  *AuthHandler
-	db           store.Database
+// Server serves the API over HTTP until it is shut down.
+type Server struct {
+	api      *api
+	server   *http.Server
+	listener net.Listener
+	errs     chan error
+	// drainDelay is how long readiness fails before the listener closes.
+	drainDelay time.Duration
 }
 
-func NewServer(mongo *store.MongoStore, auth *auth.Middleware) *api {
+func NewServer(conf *config.Config, store store.Store, auth *auth.Middleware, log *slog.Logger) *Server {
 	api := &api{
-		mongo: mongo,
+		conf:  conf,
+		store: store,
 		auth:  *auth,
+		log:   log,
 	}
-
 	api.router = configureRouter(api)
 
-	server := http.Server{
-		Addr:    "0.0.0.0:8080",
-		Handler: api.router,
+	return &Server{
+		api: api,
+		server: &http.Server{
+			Addr:              conf.Server.Addr,
+			Handler:           api.router,
+			ReadHeaderTimeout: conf.Server.ReadHeaderTimeout,
+			ReadTimeout:       conf.Server.ReadTimeout,
+			WriteTimeout:      conf.Server.WriteTimeout,
+			IdleTimeout:       conf.Server.IdleTimeout,
+		},
+		errs:       make(chan error, 1),
+		drainDelay: conf.Server.DrainDelay,
+	}
+}
+
+// Start binds the listen address and serves in the background. Errors that
+// stop serving later are reported on Err.
+func (s *Server) Start() error {
+	listener, err := net.Listen("tcp", s.server.Addr)
+	if err != nil {
+		return err
 	}
+	s.listener = listener
+	s.api.log.Info("Server listening", "addr", listener.Addr().String())
 
-	wg.Add(1)
 	go func() {
-		defer wg.Done()
-		err := server.ListenAndServe()
-		if err != nil {
-			log.Println("NewServer ListenAndServe err: ", err)
+		err := s.server.Serve(listener)
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.errs <- err
 		}
+		close(s.errs)
 	}()
 
 	return nil
 }
 
-func Wait() {
-	wg.Wait()
+// Err receives the error that stopped the server before Shutdown was
+// called, and is closed once the server stopped serving.
+func (s *Server) Err() <-chan error {
+	return s.errs
+}
+
+// Shutdown fails readiness for the drain delay so that load balancers stop
+// routing to the server, then stops accepting connections and waits for
+// in-flight requests and the work they left running. It gives up when ctx is
+// done.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.api.log.Info("Server shutting down, readiness failing")
+	s.api.draining.Store(true)
+
+	timer := time.NewTimer(s.drainDelay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+
+	err := s.server.Shutdown(ctx)
+
+	return errors.Join(err, s.api.tasks.Wait(ctx))
+}
+
+// tasks tracks goroutines that outlive the request which started them.
+type tasks struct {
+	wg sync.WaitGroup
+}
+
+// Go runs f in a new goroutine.
+func (t *tasks) Go(f func()) {
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		f()
+	}()
+}
+
+// Wait waits for the running goroutines to finish or ctx to be done.
+func (t *tasks) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		t.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
 func (a *api) Books() *BooksHandler {
@@ -59,6 +156,14 @@ func (a *api) Books() *BooksHandler {
 	return a.booksHandler
 }
 
+func (a *api) Revisions() *RevisionsHandler {
+	if a.revisionsHandler == nil {
+		a.revisionsHandler = NewRevisionsHandler(a)
+	}
+
+	return a.revisionsHandler
+}
+
 func (a *api) Auth() *AuthHandler {
 	if a.authHandler == nil {
 		a.authHandler = NewAuthHandler(a)
@@ -66,3 +171,43 @@ func (a *api) Auth() *AuthHandler {
 
 	return a.authHandler
 }
+
+func (a *api) Sessions() *SessionsHandler {
+	if a.sessionsHandler == nil {
+		a.sessionsHandler = NewSessionsHandler(a)
+	}
+
+	return a.sessionsHandler
+}
+
+func (a *api) Users() *UsersHandler {
+	if a.usersHandler == nil {
+		a.usersHandler = NewUsersHandler(a)
+	}
+
+	return a.usersHandler
+}
+
+func (a *api) Authors() *AuthorsHandler {
+	if a.authorsHandler == nil {
+		a.authorsHandler = NewAuthorsHandler(a)
+	}
+
+	return a.authorsHandler
+}
+
+func (a *api) Audit() *AuditHandler {
+	if a.auditHandler == nil {
+		a.auditHandler = NewAuditHandler(a)
+	}
+
+	return a.auditHandler
+}
+
+func (a *api) Health() *HealthHandler {
+	if a.healthHandler == nil {
+		a.healthHandler = NewHealthHandler(a)
+	}
+
+	return a.healthHandler
+}
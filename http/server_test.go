@@ -0,0 +1,88 @@
+package http
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestServer serves the test API on a free local port.
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+
+	api, _ := newTestAPI(t)
+	api.log = slog.New(slog.NewTextHandler(io.Discard, nil))
+	server := &Server{
+		api:        api,
+		server:     &http.Server{Addr: "127.0.0.1:0", Handler: api.router},
+		errs:       make(chan error, 1),
+		drainDelay: 50 * time.Millisecond,
+	}
+	assert.NoError(t, server.Start())
+
+	return server
+}
+
+func TestServerShutdownDrainsRequests(t *testing.T) {
+	server := newTestServer(t)
+	entered, release := make(chan struct{}), make(chan struct{})
+	server.api.router.GET("/slow", func(c *gin.Context) {
+		close(entered)
+		<-release
+		c.Status(http.StatusNoContent)
+	})
+	url := "http://" + server.listener.Addr().String()
+
+	responses := make(chan int, 1)
+	go func() {
+		resp, err := http.Get(url + "/slow")
+		if err != nil {
+			responses <- 0
+
+			return
+		}
+		resp.Body.Close()
+		responses <- resp.StatusCode
+	}()
+	<-entered
+
+	var emailed bool
+	server.api.tasks.Go(func() {
+		time.Sleep(20 * time.Millisecond)
+		emailed = true
+	})
+
+	shutdown := make(chan error, 1)
+	go func() { shutdown <- server.Shutdown(context.Background()) }()
+
+	assert.Eventually(t, server.api.draining.Load, time.Second, time.Millisecond)
+	rr := serveJSON(server.api, "GET", "/readyz", "", nil)
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+
+	close(release)
+	assert.Equal(t, http.StatusNoContent, <-responses)
+	assert.NoError(t, <-shutdown)
+	assert.True(t, emailed)
+
+	_, open := <-server.Err()
+	assert.False(t, open)
+	_, err := http.Get(url + "/healthz")
+	assert.Error(t, err)
+}
+
+func TestServerShutdownGivesUp(t *testing.T) {
+	server := newTestServer(t)
+	block := make(chan struct{})
+	defer close(block)
+	server.api.tasks.Go(func() { <-block })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, server.Shutdown(ctx), context.DeadlineExceeded)
+}
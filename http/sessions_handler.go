@@ -0,0 +1,77 @@
+package http
+
+import (
+	"bookService/auth"
+	"bookService/model"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SessionsHandlerInterface interface {
+	GetAll(c *gin.Context)
+	Delete(c *gin.Context)
+}
+
+type SessionsHandler struct {
+	api *api
+}
+
+func NewSessionsHandler(a *api) *SessionsHandler {
+	return &SessionsHandler{
+		api: a,
+	}
+}
+
+func (h *SessionsHandler) GetAll(c *gin.Context) {
+	claims, ok := auth.ClaimsFromContext(c)
+	if !ok {
+		logger(c).Info("GetAll ClaimsFromContext empty claims")
+		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return
+	}
+
+	results, err := h.api.store.Sessions().GetActiveByUser(c.Request.Context(), claims.BaseClaims.ID)
+	if err != nil {
+		logger(c).Error("GetAll GetActiveByUser", "err", err)
+		c.JSON(http.StatusInternalServerError, model.ErrInternalServerError)
+
+		return
+	}
+
+	for i := range results {
+		results[i].Current = results[i].ID == claims.FamilyID
+	}
+
+	answer := map[string]interface{}{
+		"items": results,
+	}
+
+	c.JSON(http.StatusOK, answer)
+}
+
+func (h *SessionsHandler) Delete(c *gin.Context) {
+	claims, ok := auth.ClaimsFromContext(c)
+	if !ok {
+		logger(c).Info("Delete ClaimsFromContext empty claims")
+		c.JSON(http.StatusUnauthorized, model.ErrUnauthorized)
+
+		return
+	}
+
+	err := h.api.auth.RevokeSession(c.Request.Context(), claims.BaseClaims.ID, c.Param("id"))
+	if err == model.ErrNotFound {
+		c.JSON(http.StatusNotFound, model.ErrNotFound)
+
+		return
+	}
+	if err != nil {
+		logger(c).Error("Delete RevokeSession", "err", err)
+		c.JSON(http.StatusInternalServerError, model.ErrInternalServerError)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "session revoked successfully"})
+}
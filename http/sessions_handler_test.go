@@ -0,0 +1,115 @@
+package http
+
+import (
+	"bookService/model"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// startSession signs in an existing user and returns the token pair.
+func startSession(t *testing.T, api *api, login string) (string, string) {
+	t.Helper()
+
+	rr := serveJSON(api, "POST", "/api/v1/signIn", "", model.Credentials{Login: login, Password: "secret"})
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var answer struct {
+		AccessToken  string `json:"accessToken"`
+		RefreshToken string `json:"refreshToken"`
+	}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &answer))
+
+	return answer.AccessToken, answer.RefreshToken
+}
+
+// refresh exchanges a refresh token the way clients do, as a form post.
+func refresh(api *api, refreshToken string) *httptest.ResponseRecorder {
+	form := url.Values{"refreshToken": {refreshToken}}
+	req := httptest.NewRequest("POST", "/api/v1/refresh", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	rr := httptest.NewRecorder()
+	api.router.ServeHTTP(rr, req)
+
+	return rr
+}
+
+// listSessions returns the sessions GET /sessions shows to the token owner.
+func listSessions(t *testing.T, api *api, token string) []model.Session {
+	t.Helper()
+
+	rr := serveJSON(api, "GET", "/api/v1/sessions", token, nil)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var answer struct {
+		Items []model.Session `json:"items"`
+	}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &answer))
+
+	return answer.Items
+}
+
+func TestSessionsListsOwnActiveSessions(t *testing.T) {
+	api, memory := newTestAPI(t)
+	signIn(t, api, "writer@example.com")
+	token, _ := startSession(t, api, "writer@example.com")
+	signIn(t, api, "reader@example.com")
+	writer, _ := memory.Users().GetByLogin(context.Background(), "writer@example.com")
+
+	sessions := listSessions(t, api, token)
+	assert.Len(t, sessions, 2)
+	current := 0
+	for _, session := range sessions {
+		assert.Equal(t, writer.ID, session.UserID)
+		if session.Current {
+			current++
+		}
+	}
+	assert.Equal(t, 1, current)
+
+	for _, session := range sessions {
+		if !session.Current {
+			rr := serveJSON(api, "DELETE", "/api/v1/session/"+session.ID, token, nil)
+			assert.Equal(t, http.StatusOK, rr.Code)
+		}
+	}
+	assert.Len(t, listSessions(t, api, token), 1)
+}
+
+func TestSessionsDeleteOtherUsersSessionIsRefused(t *testing.T) {
+	api, _ := newTestAPI(t)
+	writerToken := signIn(t, api, "writer@example.com")
+	readerToken := signIn(t, api, "reader@example.com")
+	session := listSessions(t, api, writerToken)[0]
+
+	rr := serveJSON(api, "DELETE", "/api/v1/session/"+session.ID, readerToken, nil)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	assert.Len(t, listSessions(t, api, writerToken), 1)
+}
+
+func TestSessionsDeleteRevokesRefreshToken(t *testing.T) {
+	api, _ := newTestAPI(t)
+	signIn(t, api, "writer@example.com")
+	token, refreshToken := startSession(t, api, "writer@example.com")
+	otherToken, otherRefreshToken := startSession(t, api, "writer@example.com")
+
+	var revoked model.Session
+	for _, session := range listSessions(t, api, token) {
+		if session.Current {
+			revoked = session
+		}
+	}
+	rr := serveJSON(api, "DELETE", "/api/v1/session/"+revoked.ID, otherToken, nil)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	assert.Equal(t, http.StatusUnauthorized, refresh(api, refreshToken).Code)
+	rr = serveJSON(api, "GET", "/api/v1/sessions", token, nil)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	assert.Equal(t, http.StatusOK, refresh(api, otherRefreshToken).Code)
+}
@@ -0,0 +1,48 @@
+package http
+
+import (
+	"bookService/model"
+	"bookService/store"
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTraceRequests(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	defer otel.SetTracerProvider(otel.GetTracerProvider())
+	defer otel.SetTextMapPropagator(otel.GetTextMapPropagator())
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	api, memory := newTestAPI(t)
+	api.store = store.Instrument(memory)
+	book, err := memory.Books().Insert(context.Background(), model.Book{Name: "Dune"}, 1)
+	assert.NoError(t, err)
+
+	traceparent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	rr := serveJSON(api, "GET", "/api/v1/book/"+book.PublicID(), "", nil, "traceparent", traceparent)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	spans := exporter.GetSpans()
+	byName := map[string]tracetest.SpanStub{}
+	for _, span := range spans {
+		byName[span.Name] = span
+	}
+	server, ok := byName["GET /api/v1/book/:id"]
+	assert.True(t, ok)
+	assert.Equal(t, trace.SpanKindServer, server.SpanKind)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", server.SpanContext.TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", server.Parent.SpanID().String())
+
+	find, ok := byName["store.books.Find"]
+	assert.True(t, ok)
+	assert.Equal(t, server.SpanContext.SpanID(), find.Parent.SpanID())
+}
@@ -0,0 +1,280 @@
+package http
+
+import (
+	"bookService/auth"
+	"bookService/model"
+	"bookService/store"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+type UsersHandlerInterface interface {
+	GetAll(c *gin.Context)
+	Find(c *gin.Context)
+	SetRole(c *gin.Context)
+	Lock(c *gin.Context)
+	Unlock(c *gin.Context)
+	ResetPassword(c *gin.Context)
+	Delete(c *gin.Context)
+}
+
+// UsersHandler is the admin API for user accounts. model.User never
+// serializes the password hash or the recovery token, so users are returned
+// as is.
+type UsersHandler struct {
+	api *api
+}
+
+func NewUsersHandler(a *api) *UsersHandler {
+	return &UsersHandler{
+		api: a,
+	}
+}
+
+func (h *UsersHandler) GetAll(c *gin.Context) {
+	offset, limit, err := offsetLimit(c)
+	if err != nil {
+		logger(c).Info("GetAll offsetLimit", "err", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	results, total, err := h.api.store.Users().Search(c.Request.Context(), c.Query("login"), offset, limit)
+	if err != nil {
+		logger(c).Error("GetAll Search", "err", err)
+		c.JSON(http.StatusInternalServerError, model.ErrInternalServerError)
+
+		return
+	}
+
+	answer := map[string]interface{}{
+		"items":  results,
+		"total":  total,
+		"offset": offset,
+		"limit":  limit,
+	}
+
+	c.JSON(http.StatusOK, answer)
+}
+
+func (h *UsersHandler) Find(c *gin.Context) {
+	user, ok := h.user(c)
+	if !ok {
+		return
+	}
+
+	answer := map[string]interface{}{
+		"item": user,
+	}
+
+	c.JSON(http.StatusOK, answer)
+}
+
+func (h *UsersHandler) SetRole(c *gin.Context) {
+	var roleRequest struct {
+		Role string `json:"role"`
+	}
+	if err := c.ShouldBindJSON(&roleRequest); err != nil {
+		logger(c).Info("SetRole ShouldBindJSON", "err", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	role, err := model.ParseRole(roleRequest.Role)
+	if err != nil || roleRequest.Role == "" {
+		logger(c).Info("SetRole ParseRole", "err", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return
+	}
+
+	user, ok := h.otherUser(c)
+	if !ok {
+		return
+	}
+
+	if err := h.api.store.Users().SetRole(c.Request.Context(), user.ID, role); err != nil {
+		logger(c).Error("SetRole SetRole", "err", err)
+		c.JSON(http.StatusInternalServerError, model.ErrInternalServerError)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "role updated successfully"})
+}
+
+func (h *UsersHandler) Lock(c *gin.Context) {
+	user, ok := h.otherUser(c)
+	if !ok {
+		return
+	}
+
+	if err := h.api.store.Users().SetLocked(c.Request.Context(), user.ID, true); err != nil {
+		logger(c).Error("Lock SetLocked", "err", err)
+		c.JSON(http.StatusInternalServerError, model.ErrInternalServerError)
+
+		return
+	}
+
+	if err := h.api.auth.RevokeUserSessions(c.Request.Context(), user.ID); err != nil {
+		logger(c).Error("Lock RevokeUserSessions", "err", err)
+		c.JSON(http.StatusInternalServerError, model.ErrInternalServerError)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "user locked successfully"})
+}
+
+func (h *UsersHandler) Unlock(c *gin.Context) {
+	user, ok := h.user(c)
+	if !ok {
+		return
+	}
+
+	if err := h.api.store.Users().SetLocked(c.Request.Context(), user.ID, false); err != nil {
+		logger(c).Error("Unlock SetLocked", "err", err)
+		c.JSON(http.StatusInternalServerError, model.ErrInternalServerError)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "user unlocked successfully"})
+}
+
+// ResetPassword signs the user out everywhere and blocks sign in until a new
+// password is set through the emailed recovery link.
+func (h *UsersHandler) ResetPassword(c *gin.Context) {
+	user, ok := h.user(c)
+	if !ok {
+		return
+	}
+
+	recoveryToken, err := generateRecoveryToken()
+	if err != nil {
+		logger(c).Error("ResetPassword generateRecoveryToken", "err", err)
+		c.JSON(http.StatusInternalServerError, model.ErrInternalServerError)
+
+		return
+	}
+
+	if err := h.api.store.Users().RequirePasswordReset(c.Request.Context(), user.ID, recoveryToken); err != nil {
+		logger(c).Error("ResetPassword RequirePasswordReset", "err", err)
+		c.JSON(http.StatusInternalServerError, model.ErrInternalServerError)
+
+		return
+	}
+
+	if err := h.api.auth.RevokeUserSessions(c.Request.Context(), user.ID); err != nil {
+		logger(c).Error("ResetPassword RevokeUserSessions", "err", err)
+		c.JSON(http.StatusInternalServerError, model.ErrInternalServerError)
+
+		return
+	}
+
+	log := logger(c)
+	h.api.tasks.Go(func() {
+		if err := sendRecoveryEmail(h.api.conf.Mail, user.Login, recoveryToken); err != nil {
+			log.Info("ResetPassword sendRecoveryEmail", "err", err)
+		}
+	})
+
+	c.JSON(http.StatusOK, gin.H{"message": "password reset required"})
+}
+
+func (h *UsersHandler) Delete(c *gin.Context) {
+	user, ok := h.otherUser(c)
+	if !ok {
+		return
+	}
+
+	if err := h.api.auth.RevokeUserSessions(c.Request.Context(), user.ID); err != nil {
+		logger(c).Error("Delete RevokeUserSessions", "err", err)
+		c.JSON(http.StatusInternalServerError, model.ErrInternalServerError)
+
+		return
+	}
+
+	if err := h.api.store.Users().Delete(c.Request.Context(), user.ID); err != nil {
+		logger(c).Error("Delete Delete", "err", err)
+		c.JSON(http.StatusInternalServerError, model.ErrInternalServerError)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "user deleted successfully"})
+}
+
+// user loads the user named by the id path parameter, writing the error
+// response if there is none.
+func (h *UsersHandler) user(c *gin.Context) (model.User, bool) {
+	ID, err := strconv.ParseUint(c.Param("id"), DecimalBase, BitSize64)
+	if err != nil {
+		logger(c).Info("user ParseUint", "err", err)
+		c.JSON(http.StatusBadRequest, model.ErrInvalidBody)
+
+		return model.User{}, false
+	}
+
+	user, err := h.api.store.Users().Find(c.Request.Context(), ID)
+	if err != nil {
+		if err == store.ErrNotFound {
+			logger(c).Info("user Find", "err", err)
+			c.JSON(http.StatusNotFound, model.ErrNotFound)
+		} else {
+			logger(c).Error("user Find", "err", err)
+			c.JSON(http.StatusInternalServerError, model.ErrInternalServerError)
+		}
+
+		return model.User{}, false
+	}
+
+	return user, true
+}
+
+// otherUser is user for changes an admin must not make to their own account,
+// so that the last admin cannot lock themselves out.
+func (h *UsersHandler) otherUser(c *gin.Context) (model.User, bool) {
+	user, ok := h.user(c)
+	if !ok {
+		return model.User{}, false
+	}
+
+	claims, ok := auth.ClaimsFromContext(c)
+	if !ok || claims.BaseClaims.ID == user.ID {
+		logger(c).Info("otherUser acting on own account")
+		c.JSON(http.StatusForbidden, model.ErrForbidden)
+
+		return model.User{}, false
+	}
+
+	return user, true
+}
+
+// offsetLimit parses the offset and limit query parameters of paged lists.
+func offsetLimit(c *gin.Context) (int, int, error) {
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		return 0, 0, fmt.Errorf("invalid offset %q", c.Query("offset"))
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultPageLimit)))
+	if err != nil || limit <= 0 {
+		return 0, 0, fmt.Errorf("invalid limit %q", c.Query("limit"))
+	}
+	if limit > maxPageLimit {
+		limit = maxPageLimit
+	}
+
+	return offset, limit, nil
+}
@@ -0,0 +1,162 @@
+package http
+
+import (
+	"bookService/mocks"
+	"bookService/model"
+	"bookService/store"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetAllUsersHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUsersHandler := mocks.NewMockUsersHandlerInterface(ctrl)
+
+	mockUsersHandler.EXPECT().GetAll(gomock.Any()).Return()
+
+	req, _ := http.NewRequest("GET", "/users?login=test&limit=10", nil)
+
+	router := gin.Default()
+	router.GET("/users", func(c *gin.Context) {
+		mockUsersHandler.GetAll(c)
+	})
+	router.ServeHTTP(httptest.NewRecorder(), req)
+}
+
+func TestLockUserHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUsersHandler := mocks.NewMockUsersHandlerInterface(ctrl)
+
+	mockUsersHandler.EXPECT().Lock(gomock.Any()).Return()
+
+	req, _ := http.NewRequest("POST", "/user/123/lock", nil)
+
+	router := gin.Default()
+	router.POST("/user/:id/lock", func(c *gin.Context) {
+		mockUsersHandler.Lock(c)
+	})
+	router.ServeHTTP(httptest.NewRecorder(), req)
+}
+
+func TestDeleteUserHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUsersHandler := mocks.NewMockUsersHandlerInterface(ctrl)
+
+	mockUsersHandler.EXPECT().Delete(gomock.Any()).Return()
+
+	req, _ := http.NewRequest("DELETE", "/user/123", nil)
+
+	router := gin.Default()
+	router.DELETE("/user/:id", func(c *gin.Context) {
+		mockUsersHandler.Delete(c)
+	})
+	router.ServeHTTP(httptest.NewRecorder(), req)
+}
+
+// signInAs signs up a user with role and returns its access token.
+func signInAs(t *testing.T, api *api, memory *store.MemoryStore, login string, role model.Role) (string, model.User) {
+	t.Helper()
+
+	token := signIn(t, api, login)
+	user, err := memory.Users().GetByLogin(context.Background(), login)
+	assert.NoError(t, err)
+	assert.NoError(t, memory.Users().SetRole(context.Background(), user.ID, role))
+
+	return token, *user
+}
+
+func TestUsersRequireAdmin(t *testing.T) {
+	api, memory := newTestAPI(t)
+	token, _ := signInAs(t, api, memory, "librarian@example.com", model.RoleLibrarian)
+	_, reader := signInAs(t, api, memory, "reader@example.com", model.RoleReader)
+
+	rr := serveJSON(api, "GET", "/api/v1/users", token, nil)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	rr = serveJSON(api, "POST", fmt.Sprintf("/api/v1/user/%d/lock", reader.ID), token, nil)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+}
+
+func TestUsersRefuseOwnAccount(t *testing.T) {
+	api, memory := newTestAPI(t)
+	token, admin := signInAs(t, api, memory, "admin@example.com", model.RoleAdmin)
+	path := fmt.Sprintf("/api/v1/user/%d", admin.ID)
+
+	rr := serveJSON(api, "PUT", path+"/role", token, map[string]string{"role": "reader"})
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	rr = serveJSON(api, "POST", path+"/lock", token, nil)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	rr = serveJSON(api, "DELETE", path, token, nil)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	user, err := memory.Users().Find(context.Background(), admin.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, model.RoleAdmin, user.Role)
+	assert.False(t, user.Locked)
+}
+
+func TestLockRevokesSessions(t *testing.T) {
+	api, memory := newTestAPI(t)
+	token, _ := signInAs(t, api, memory, "admin@example.com", model.RoleAdmin)
+	readerToken, reader := signInAs(t, api, memory, "reader@example.com", model.RoleReader)
+	_, refreshToken := startSession(t, api, "reader@example.com")
+
+	rr := serveJSON(api, "GET", "/api/v1/sessions", readerToken, nil)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	rr = serveJSON(api, "POST", fmt.Sprintf("/api/v1/user/%d/lock", reader.ID), token, nil)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	rr = serveJSON(api, "GET", "/api/v1/sessions", readerToken, nil)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	assert.Equal(t, http.StatusUnauthorized, refresh(api, refreshToken).Code)
+	sessions, err := memory.Sessions().GetActiveByUser(context.Background(), reader.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, sessions)
+}
+
+func TestSetRoleRejectsUnknownRoles(t *testing.T) {
+	api, memory := newTestAPI(t)
+	token, _ := signInAs(t, api, memory, "admin@example.com", model.RoleAdmin)
+	_, reader := signInAs(t, api, memory, "reader@example.com", model.RoleReader)
+	path := fmt.Sprintf("/api/v1/user/%d/role", reader.ID)
+
+	for _, role := range []string{"", "superuser", "root"} {
+		rr := serveJSON(api, "PUT", path, token, map[string]string{"role": role})
+		assert.Equal(t, http.StatusBadRequest, rr.Code, role)
+	}
+
+	rr := serveJSON(api, "PUT", path, token, map[string]string{"role": "librarian"})
+	assert.Equal(t, http.StatusOK, rr.Code)
+	user, _ := memory.Users().Find(context.Background(), reader.ID)
+	assert.Equal(t, model.RoleLibrarian, user.Role)
+}
+
+func TestGetAllUsersHidesSecrets(t *testing.T) {
+	api, memory := newTestAPI(t)
+	token, _ := signInAs(t, api, memory, "admin@example.com", model.RoleAdmin)
+	_, reader := signInAs(t, api, memory, "reader@example.com", model.RoleReader)
+	assert.NoError(t, memory.Users().SaveRecoveryToken(context.Background(), reader.ID, "recovery-secret"))
+	stored, _ := memory.Users().Find(context.Background(), reader.ID)
+
+	rr := serveJSON(api, "GET", "/api/v1/users", token, nil)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	body := rr.Body.String()
+	assert.Contains(t, body, "reader@example.com")
+	assert.NotContains(t, body, `"password"`)
+	assert.NotContains(t, body, stored.Password)
+	assert.NotContains(t, body, "recovery")
+	assert.NotContains(t, body, "recovery-secret")
+}
@@ -0,0 +1,44 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewRedactsSensitiveValues(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, FormatJSON, "info")
+	assert.NoError(t, err)
+
+	logger.Info("signed in", "user_id", 7, "refreshToken", "abc", "new_password", "secret")
+	logger.Debug("not shown")
+
+	var line map[string]interface{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	assert.Equal(t, "signed in", line["msg"])
+	assert.Equal(t, float64(7), line["user_id"])
+	assert.Equal(t, Redacted, line["refreshToken"])
+	assert.Equal(t, Redacted, line["new_password"])
+}
+
+func TestNewRejectsUnknownSettings(t *testing.T) {
+	_, err := New(&bytes.Buffer{}, "xml", "info")
+	assert.Error(t, err)
+	_, err = New(&bytes.Buffer{}, FormatText, "loud")
+	assert.Error(t, err)
+}
+
+func TestFromContext(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, FormatText, "debug")
+	assert.NoError(t, err)
+	ctx := NewContext(context.Background(), logger.With("request_id", "r1"))
+
+	FromContext(ctx).Debug("hello")
+	assert.Contains(t, buf.String(), "request_id=r1")
+	assert.NotNil(t, FromContext(context.Background()))
+}
@@ -4,32 +4,138 @@ import (
 	"bookService/auth"
 	"bookService/config"
 	"bookService/http"
+	"bookService/logging"
 	"bookService/store"
-	"log"
+	"bookService/tracing"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 )
 
 func main() {
-	conf, err := config.New()
+	os.Exit(run())
+}
+
+// run is the whole process and returns its exit status, so that the
+// deferred cleanup, such as flushing the spans, also runs on failures.
+func run() int {
+	conf, args, err := config.New()
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
 	if err != nil {
-		log.Fatalf("Can't read config file: %v", err)
+		fmt.Fprintf(os.Stderr, "invalid configuration:\n%v\n", err)
+
+		return 2
 	}
-	mongoStore, err := store.NewMongoStore(conf)
+	logger, err := logging.New(os.Stderr, conf.Log.Format, conf.Log.Level)
+	if err != nil {
+		return failed(slog.Default(), "main logging.New", err)
+	}
+	// Code without a context, and the standard log package, write through
+	// the same logger.
+	slog.SetDefault(logger)
+	ctx := logging.NewContext(context.Background(), logger)
+
+	if len(args) > 0 {
+		switch args[0] {
+		case "migrate":
+			err = runMigrate(ctx, conf, args[1:], os.Stdout)
+		case "config":
+			err = runConfig(conf, args[1:], os.Stdout)
+		default:
+			err = fmt.Errorf("unknown command %q, use migrate or config", args[0])
+		}
+		if err != nil {
+			return failed(logger, args[0], err)
+		}
+
+		return 0
+	}
+
+	shutdownTracing, err := tracing.Setup(ctx, conf.Trace)
+	if err != nil {
+		return failed(logger, "main tracing.Setup", err)
+	}
+	defer shutdownTracing(context.Background())
+
+	dataStore, err := store.New(ctx, conf)
 	if err != nil {
-		log.Fatalf("main NewMongoStore err: %v", err)
+		return failed(logger, "main store.New", err)
 	}
-	atKey, err := auth.GenerateECDSAPrivateKey()
+	atKeys, err := auth.LoadKeySet(conf.Auth.AccessKeyFile, conf.Auth.AccessKeysDir, conf.Auth.AccessTokenTTL)
 	if err != nil {
-		log.Fatalf("main generateECDSAPrivateKey atKey err: %v", err)
+		return failed(logger, "main LoadKeySet atKeys", err)
 	}
-	rtKey, err := auth.GenerateECDSAPrivateKey()
+	rtKeys, err := auth.LoadKeySet(conf.Auth.RefreshKeyFile, conf.Auth.RefreshKeysDir, conf.Auth.RefreshTokenTTL)
 	if err != nil {
-		log.Fatalf("main generateECDSAPrivateKey rtKey err: %v", err)
+		return failed(logger, "main LoadKeySet rtKeys", err)
+	}
+	// Background loops run until the server has shut down, so that the
+	// trash purge never races the store being closed.
+	var workers sync.WaitGroup
+	workersCtx, stopWorkers := context.WithCancel(ctx)
+	defer stopWorkers()
+	if conf.Auth.KeyRotationInterval > 0 {
+		goWorker(&workers, func() { atKeys.StartRotation(workersCtx, conf.Auth.KeyRotationInterval) })
+		goWorker(&workers, func() { rtKeys.StartRotation(workersCtx, conf.Auth.KeyRotationInterval) })
+	}
+	if conf.Store.TrashRetention > 0 {
+		goWorker(&workers, func() {
+			store.StartTrashPurge(workersCtx, dataStore.Books(), conf.Store.TrashRetention, conf.Store.TrashPurgeInterval)
+		})
+	}
+
+	middleware := auth.NewAuthMiddleware(atKeys, rtKeys, dataStore)
+	middleware.SetTokenTTLs(conf.Auth.AccessTokenTTL, conf.Auth.RefreshTokenTTL)
+	server := http.NewServer(conf, dataStore, middleware, logger)
+	if err := server.Start(); err != nil {
+		return failed(logger, "main server.Start", err)
 	}
-	middleware := auth.NewAuthMiddleware(atKey, rtKey, mongoStore)
-	if err := http.NewServer(mongoStore, middleware); err != nil {
-		log.Fatalf("Error starting HTTP server: %v", err)
 
-		return
+	signals, stopSignals := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	select {
+	case <-signals.Done():
+		logger.Info("main received signal, shutting down")
+	case err := <-server.Err():
+		logger.Error("main server stopped serving", "err", err)
+	}
+	// A second signal kills the process right away.
+	stopSignals()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), conf.Server.ShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		logger.Error("main server.Shutdown", "err", err)
+	}
+	stopWorkers()
+	workers.Wait()
+	if err := dataStore.Close(shutdownCtx); err != nil {
+		logger.Error("main dataStore.Close", "err", err)
 	}
-	http.Wait()
+	logger.Info("main stopped")
+
+	return 0
+}
+
+// goWorker runs f in a goroutine tracked by workers.
+func goWorker(workers *sync.WaitGroup, f func()) {
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		f()
+	}()
+}
+
+// failed logs the error that ends run and returns the exit status.
+func failed(logger *slog.Logger, msg string, err error) int {
+	logger.Error(msg, "err", err)
+
+	return 1
 }
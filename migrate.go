@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bookService/config"
+	"bookService/store"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+const migrateUsage = `usage: bookService [flags] migrate up [-seed]
+       bookService [flags] migrate down [steps]
+       bookService [flags] migrate status`
+
+// runMigrate is the migrate command. It always works on MongoDB, the
+// memory backend has no schema.
+func runMigrate(ctx context.Context, conf *config.Config, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	mongo, err := store.NewMongoStore(conf)
+	if err != nil {
+		return err
+	}
+	defer mongo.Close(context.Background())
+
+	switch args[0] {
+	case "up":
+		flags := flag.NewFlagSet("migrate up", flag.ContinueOnError)
+		seed := flags.Bool("seed", false, "insert sample authors and books into an empty catalog")
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+
+		applied, err := mongo.MigrateUp(ctx)
+		for _, migration := range applied {
+			fmt.Fprintf(out, "applied %d %s\n", migration.Version, migration.Description)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Fprintln(out, "nothing to apply")
+		}
+		if *seed {
+			return store.SeedFixtures(ctx, mongo)
+		}
+
+		return nil
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("migrate down: steps must be a positive number")
+			}
+		}
+
+		reverted, err := mongo.MigrateDown(ctx, steps)
+		for _, migration := range reverted {
+			fmt.Fprintf(out, "reverted %d %s\n", migration.Version, migration.Description)
+		}
+
+		return err
+	case "status":
+		statuses, err := mongo.MigrationStatus(ctx)
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			applied := "pending"
+			if status.AppliedAt != nil {
+				applied = status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(out, "%3d  %-19s  %s\n", status.Version, applied, status.Description)
+		}
+
+		return nil
+	}
+
+	return errors.New(migrateUsage)
+}
@@ -0,0 +1,59 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: bookService/http (interfaces: AuditHandlerInterface)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+
+	gin "github.com/gin-gonic/gin"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockAuditHandlerInterface is a mock of AuditHandlerInterface interface.
+type MockAuditHandlerInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuditHandlerInterfaceMockRecorder
+}
+
+// MockAuditHandlerInterfaceMockRecorder is the mock recorder for MockAuditHandlerInterface.
+type MockAuditHandlerInterfaceMockRecorder struct {
+	mock *MockAuditHandlerInterface
+}
+
+// NewMockAuditHandlerInterface creates a new mock instance.
+func NewMockAuditHandlerInterface(ctrl *gomock.Controller) *MockAuditHandlerInterface {
+	mock := &MockAuditHandlerInterface{ctrl: ctrl}
+	mock.recorder = &MockAuditHandlerInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuditHandlerInterface) EXPECT() *MockAuditHandlerInterfaceMockRecorder {
+	return m.recorder
+}
+
+// Export mocks base method.
+func (m *MockAuditHandlerInterface) Export(arg0 *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Export", arg0)
+}
+
+// Export indicates an expected call of Export.
+func (mr *MockAuditHandlerInterfaceMockRecorder) Export(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Export", reflect.TypeOf((*MockAuditHandlerInterface)(nil).Export), arg0)
+}
+
+// GetAll mocks base method.
+func (m *MockAuditHandlerInterface) GetAll(arg0 *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "GetAll", arg0)
+}
+
+// GetAll indicates an expected call of GetAll.
+func (mr *MockAuditHandlerInterfaceMockRecorder) GetAll(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockAuditHandlerInterface)(nil).GetAll), arg0)
+}
@@ -1,5 +1,5 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: http/auth_handler.go
+// Source: bookService/http (interfaces: AuthHandlerInterface)
 
 // Package mocks is a generated GoMock package.
 package mocks
@@ -34,62 +34,98 @@ func (m *MockAuthHandlerInterface) EXPECT() *MockAuthHandlerInterfaceMockRecorde
 	return m.recorder
 }
 
+// JWKS mocks base method.
+func (m *MockAuthHandlerInterface) JWKS(arg0 *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "JWKS", arg0)
+}
+
+// JWKS indicates an expected call of JWKS.
+func (mr *MockAuthHandlerInterfaceMockRecorder) JWKS(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "JWKS", reflect.TypeOf((*MockAuthHandlerInterface)(nil).JWKS), arg0)
+}
+
+// Logout mocks base method.
+func (m *MockAuthHandlerInterface) Logout(arg0 *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Logout", arg0)
+}
+
+// Logout indicates an expected call of Logout.
+func (mr *MockAuthHandlerInterfaceMockRecorder) Logout(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Logout", reflect.TypeOf((*MockAuthHandlerInterface)(nil).Logout), arg0)
+}
+
+// LogoutAll mocks base method.
+func (m *MockAuthHandlerInterface) LogoutAll(arg0 *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "LogoutAll", arg0)
+}
+
+// LogoutAll indicates an expected call of LogoutAll.
+func (mr *MockAuthHandlerInterfaceMockRecorder) LogoutAll(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LogoutAll", reflect.TypeOf((*MockAuthHandlerInterface)(nil).LogoutAll), arg0)
+}
+
 // Recover mocks base method.
-func (m *MockAuthHandlerInterface) Recover(c *gin.Context) {
+func (m *MockAuthHandlerInterface) Recover(arg0 *gin.Context) {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "Recover", c)
+	m.ctrl.Call(m, "Recover", arg0)
 }
 
 // Recover indicates an expected call of Recover.
-func (mr *MockAuthHandlerInterfaceMockRecorder) Recover(c interface{}) *gomock.Call {
+func (mr *MockAuthHandlerInterfaceMockRecorder) Recover(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Recover", reflect.TypeOf((*MockAuthHandlerInterface)(nil).Recover), c)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Recover", reflect.TypeOf((*MockAuthHandlerInterface)(nil).Recover), arg0)
 }
 
 // Refresh mocks base method.
-func (m *MockAuthHandlerInterface) Refresh(c *gin.Context) {
+func (m *MockAuthHandlerInterface) Refresh(arg0 *gin.Context) {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "Refresh", c)
+	m.ctrl.Call(m, "Refresh", arg0)
 }
 
 // Refresh indicates an expected call of Refresh.
-func (mr *MockAuthHandlerInterfaceMockRecorder) Refresh(c interface{}) *gomock.Call {
+func (mr *MockAuthHandlerInterfaceMockRecorder) Refresh(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Refresh", reflect.TypeOf((*MockAuthHandlerInterface)(nil).Refresh), c)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Refresh", reflect.TypeOf((*MockAuthHandlerInterface)(nil).Refresh), arg0)
 }
 
 // SetNewPassword mocks base method.
-func (m *MockAuthHandlerInterface) SetNewPassword(c *gin.Context) {
+func (m *MockAuthHandlerInterface) SetNewPassword(arg0 *gin.Context) {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "SetNewPassword", c)
+	m.ctrl.Call(m, "SetNewPassword", arg0)
 }
 
 // SetNewPassword indicates an expected call of SetNewPassword.
-func (mr *MockAuthHandlerInterfaceMockRecorder) SetNewPassword(c interface{}) *gomock.Call {
+func (mr *MockAuthHandlerInterfaceMockRecorder) SetNewPassword(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetNewPassword", reflect.TypeOf((*MockAuthHandlerInterface)(nil).SetNewPassword), c)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetNewPassword", reflect.TypeOf((*MockAuthHandlerInterface)(nil).SetNewPassword), arg0)
 }
 
 // SignIn mocks base method.
-func (m *MockAuthHandlerInterface) SignIn(c *gin.Context) {
+func (m *MockAuthHandlerInterface) SignIn(arg0 *gin.Context) {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "SignIn", c)
+	m.ctrl.Call(m, "SignIn", arg0)
 }
 
 // SignIn indicates an expected call of SignIn.
-func (mr *MockAuthHandlerInterfaceMockRecorder) SignIn(c interface{}) *gomock.Call {
+func (mr *MockAuthHandlerInterfaceMockRecorder) SignIn(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SignIn", reflect.TypeOf((*MockAuthHandlerInterface)(nil).SignIn), c)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SignIn", reflect.TypeOf((*MockAuthHandlerInterface)(nil).SignIn), arg0)
 }
 
 // SignUp mocks base method.
-func (m *MockAuthHandlerInterface) SignUp(c *gin.Context) {
+func (m *MockAuthHandlerInterface) SignUp(arg0 *gin.Context) {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "SignUp", c)
+	m.ctrl.Call(m, "SignUp", arg0)
 }
 
 // SignUp indicates an expected call of SignUp.
-func (mr *MockAuthHandlerInterfaceMockRecorder) SignUp(c interface{}) *gomock.Call {
+func (mr *MockAuthHandlerInterfaceMockRecorder) SignUp(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SignUp", reflect.TypeOf((*MockAuthHandlerInterface)(nil).SignUp), c)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SignUp", reflect.TypeOf((*MockAuthHandlerInterface)(nil).SignUp), arg0)
 }
@@ -0,0 +1,95 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: bookService/http (interfaces: AuthorsHandlerInterface)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+
+	gin "github.com/gin-gonic/gin"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockAuthorsHandlerInterface is a mock of AuthorsHandlerInterface interface.
+type MockAuthorsHandlerInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuthorsHandlerInterfaceMockRecorder
+}
+
+// MockAuthorsHandlerInterfaceMockRecorder is the mock recorder for MockAuthorsHandlerInterface.
+type MockAuthorsHandlerInterfaceMockRecorder struct {
+	mock *MockAuthorsHandlerInterface
+}
+
+// NewMockAuthorsHandlerInterface creates a new mock instance.
+func NewMockAuthorsHandlerInterface(ctrl *gomock.Controller) *MockAuthorsHandlerInterface {
+	mock := &MockAuthorsHandlerInterface{ctrl: ctrl}
+	mock.recorder = &MockAuthorsHandlerInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuthorsHandlerInterface) EXPECT() *MockAuthorsHandlerInterfaceMockRecorder {
+	return m.recorder
+}
+
+// Add mocks base method.
+func (m *MockAuthorsHandlerInterface) Add(arg0 *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Add", arg0)
+}
+
+// Add indicates an expected call of Add.
+func (mr *MockAuthorsHandlerInterfaceMockRecorder) Add(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Add", reflect.TypeOf((*MockAuthorsHandlerInterface)(nil).Add), arg0)
+}
+
+// Delete mocks base method.
+func (m *MockAuthorsHandlerInterface) Delete(arg0 *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Delete", arg0)
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockAuthorsHandlerInterfaceMockRecorder) Delete(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockAuthorsHandlerInterface)(nil).Delete), arg0)
+}
+
+// Find mocks base method.
+func (m *MockAuthorsHandlerInterface) Find(arg0 *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Find", arg0)
+}
+
+// Find indicates an expected call of Find.
+func (mr *MockAuthorsHandlerInterfaceMockRecorder) Find(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Find", reflect.TypeOf((*MockAuthorsHandlerInterface)(nil).Find), arg0)
+}
+
+// GetAll mocks base method.
+func (m *MockAuthorsHandlerInterface) GetAll(arg0 *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "GetAll", arg0)
+}
+
+// GetAll indicates an expected call of GetAll.
+func (mr *MockAuthorsHandlerInterfaceMockRecorder) GetAll(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockAuthorsHandlerInterface)(nil).GetAll), arg0)
+}
+
+// Update mocks base method.
+func (m *MockAuthorsHandlerInterface) Update(arg0 *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Update", arg0)
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockAuthorsHandlerInterfaceMockRecorder) Update(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockAuthorsHandlerInterface)(nil).Update), arg0)
+}
@@ -82,6 +82,42 @@ func (mr *MockBooksHandlerInterfaceMockRecorder) GetAll(arg0 interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockBooksHandlerInterface)(nil).GetAll), arg0)
 }
 
+// Patch mocks base method.
+func (m *MockBooksHandlerInterface) Patch(arg0 *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Patch", arg0)
+}
+
+// Patch indicates an expected call of Patch.
+func (mr *MockBooksHandlerInterfaceMockRecorder) Patch(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Patch", reflect.TypeOf((*MockBooksHandlerInterface)(nil).Patch), arg0)
+}
+
+// Restore mocks base method.
+func (m *MockBooksHandlerInterface) Restore(arg0 *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Restore", arg0)
+}
+
+// Restore indicates an expected call of Restore.
+func (mr *MockBooksHandlerInterfaceMockRecorder) Restore(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockBooksHandlerInterface)(nil).Restore), arg0)
+}
+
+// Trash mocks base method.
+func (m *MockBooksHandlerInterface) Trash(arg0 *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Trash", arg0)
+}
+
+// Trash indicates an expected call of Trash.
+func (mr *MockBooksHandlerInterfaceMockRecorder) Trash(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Trash", reflect.TypeOf((*MockBooksHandlerInterface)(nil).Trash), arg0)
+}
+
 // Update mocks base method.
 func (m *MockBooksHandlerInterface) Update(arg0 *gin.Context) {
 	m.ctrl.T.Helper()
@@ -0,0 +1,59 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: bookService/http (interfaces: HealthHandlerInterface)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+
+	gin "github.com/gin-gonic/gin"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockHealthHandlerInterface is a mock of HealthHandlerInterface interface.
+type MockHealthHandlerInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockHealthHandlerInterfaceMockRecorder
+}
+
+// MockHealthHandlerInterfaceMockRecorder is the mock recorder for MockHealthHandlerInterface.
+type MockHealthHandlerInterfaceMockRecorder struct {
+	mock *MockHealthHandlerInterface
+}
+
+// NewMockHealthHandlerInterface creates a new mock instance.
+func NewMockHealthHandlerInterface(ctrl *gomock.Controller) *MockHealthHandlerInterface {
+	mock := &MockHealthHandlerInterface{ctrl: ctrl}
+	mock.recorder = &MockHealthHandlerInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockHealthHandlerInterface) EXPECT() *MockHealthHandlerInterfaceMockRecorder {
+	return m.recorder
+}
+
+// Live mocks base method.
+func (m *MockHealthHandlerInterface) Live(arg0 *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Live", arg0)
+}
+
+// Live indicates an expected call of Live.
+func (mr *MockHealthHandlerInterfaceMockRecorder) Live(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Live", reflect.TypeOf((*MockHealthHandlerInterface)(nil).Live), arg0)
+}
+
+// Ready mocks base method.
+func (m *MockHealthHandlerInterface) Ready(arg0 *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Ready", arg0)
+}
+
+// Ready indicates an expected call of Ready.
+func (mr *MockHealthHandlerInterfaceMockRecorder) Ready(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ready", reflect.TypeOf((*MockHealthHandlerInterface)(nil).Ready), arg0)
+}
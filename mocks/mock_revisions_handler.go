@@ -0,0 +1,71 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: bookService/http (interfaces: RevisionsHandlerInterface)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+
+	gin "github.com/gin-gonic/gin"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockRevisionsHandlerInterface is a mock of RevisionsHandlerInterface interface.
+type MockRevisionsHandlerInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockRevisionsHandlerInterfaceMockRecorder
+}
+
+// MockRevisionsHandlerInterfaceMockRecorder is the mock recorder for MockRevisionsHandlerInterface.
+type MockRevisionsHandlerInterfaceMockRecorder struct {
+	mock *MockRevisionsHandlerInterface
+}
+
+// NewMockRevisionsHandlerInterface creates a new mock instance.
+func NewMockRevisionsHandlerInterface(ctrl *gomock.Controller) *MockRevisionsHandlerInterface {
+	mock := &MockRevisionsHandlerInterface{ctrl: ctrl}
+	mock.recorder = &MockRevisionsHandlerInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRevisionsHandlerInterface) EXPECT() *MockRevisionsHandlerInterfaceMockRecorder {
+	return m.recorder
+}
+
+// Diff mocks base method.
+func (m *MockRevisionsHandlerInterface) Diff(arg0 *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Diff", arg0)
+}
+
+// Diff indicates an expected call of Diff.
+func (mr *MockRevisionsHandlerInterfaceMockRecorder) Diff(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Diff", reflect.TypeOf((*MockRevisionsHandlerInterface)(nil).Diff), arg0)
+}
+
+// GetAll mocks base method.
+func (m *MockRevisionsHandlerInterface) GetAll(arg0 *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "GetAll", arg0)
+}
+
+// GetAll indicates an expected call of GetAll.
+func (mr *MockRevisionsHandlerInterfaceMockRecorder) GetAll(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockRevisionsHandlerInterface)(nil).GetAll), arg0)
+}
+
+// Revert mocks base method.
+func (m *MockRevisionsHandlerInterface) Revert(arg0 *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Revert", arg0)
+}
+
+// Revert indicates an expected call of Revert.
+func (mr *MockRevisionsHandlerInterfaceMockRecorder) Revert(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revert", reflect.TypeOf((*MockRevisionsHandlerInterface)(nil).Revert), arg0)
+}
@@ -0,0 +1,59 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: bookService/http (interfaces: SessionsHandlerInterface)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+
+	gin "github.com/gin-gonic/gin"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockSessionsHandlerInterface is a mock of SessionsHandlerInterface interface.
+type MockSessionsHandlerInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockSessionsHandlerInterfaceMockRecorder
+}
+
+// MockSessionsHandlerInterfaceMockRecorder is the mock recorder for MockSessionsHandlerInterface.
+type MockSessionsHandlerInterfaceMockRecorder struct {
+	mock *MockSessionsHandlerInterface
+}
+
+// NewMockSessionsHandlerInterface creates a new mock instance.
+func NewMockSessionsHandlerInterface(ctrl *gomock.Controller) *MockSessionsHandlerInterface {
+	mock := &MockSessionsHandlerInterface{ctrl: ctrl}
+	mock.recorder = &MockSessionsHandlerInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSessionsHandlerInterface) EXPECT() *MockSessionsHandlerInterfaceMockRecorder {
+	return m.recorder
+}
+
+// Delete mocks base method.
+func (m *MockSessionsHandlerInterface) Delete(arg0 *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Delete", arg0)
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockSessionsHandlerInterfaceMockRecorder) Delete(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockSessionsHandlerInterface)(nil).Delete), arg0)
+}
+
+// GetAll mocks base method.
+func (m *MockSessionsHandlerInterface) GetAll(arg0 *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "GetAll", arg0)
+}
+
+// GetAll indicates an expected call of GetAll.
+func (mr *MockSessionsHandlerInterfaceMockRecorder) GetAll(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockSessionsHandlerInterface)(nil).GetAll), arg0)
+}
@@ -0,0 +1,119 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: bookService/http (interfaces: UsersHandlerInterface)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+
+	gin "github.com/gin-gonic/gin"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockUsersHandlerInterface is a mock of UsersHandlerInterface interface.
+type MockUsersHandlerInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockUsersHandlerInterfaceMockRecorder
+}
+
+// MockUsersHandlerInterfaceMockRecorder is the mock recorder for MockUsersHandlerInterface.
+type MockUsersHandlerInterfaceMockRecorder struct {
+	mock *MockUsersHandlerInterface
+}
+
+// NewMockUsersHandlerInterface creates a new mock instance.
+func NewMockUsersHandlerInterface(ctrl *gomock.Controller) *MockUsersHandlerInterface {
+	mock := &MockUsersHandlerInterface{ctrl: ctrl}
+	mock.recorder = &MockUsersHandlerInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUsersHandlerInterface) EXPECT() *MockUsersHandlerInterfaceMockRecorder {
+	return m.recorder
+}
+
+// Delete mocks base method.
+func (m *MockUsersHandlerInterface) Delete(arg0 *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Delete", arg0)
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockUsersHandlerInterfaceMockRecorder) Delete(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockUsersHandlerInterface)(nil).Delete), arg0)
+}
+
+// Find mocks base method.
+func (m *MockUsersHandlerInterface) Find(arg0 *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Find", arg0)
+}
+
+// Find indicates an expected call of Find.
+func (mr *MockUsersHandlerInterfaceMockRecorder) Find(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Find", reflect.TypeOf((*MockUsersHandlerInterface)(nil).Find), arg0)
+}
+
+// GetAll mocks base method.
+func (m *MockUsersHandlerInterface) GetAll(arg0 *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "GetAll", arg0)
+}
+
+// GetAll indicates an expected call of GetAll.
+func (mr *MockUsersHandlerInterfaceMockRecorder) GetAll(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockUsersHandlerInterface)(nil).GetAll), arg0)
+}
+
+// Lock mocks base method.
+func (m *MockUsersHandlerInterface) Lock(arg0 *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Lock", arg0)
+}
+
+// Lock indicates an expected call of Lock.
+func (mr *MockUsersHandlerInterfaceMockRecorder) Lock(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Lock", reflect.TypeOf((*MockUsersHandlerInterface)(nil).Lock), arg0)
+}
+
+// ResetPassword mocks base method.
+func (m *MockUsersHandlerInterface) ResetPassword(arg0 *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ResetPassword", arg0)
+}
+
+// ResetPassword indicates an expected call of ResetPassword.
+func (mr *MockUsersHandlerInterfaceMockRecorder) ResetPassword(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetPassword", reflect.TypeOf((*MockUsersHandlerInterface)(nil).ResetPassword), arg0)
+}
+
+// SetRole mocks base method.
+func (m *MockUsersHandlerInterface) SetRole(arg0 *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetRole", arg0)
+}
+
+// SetRole indicates an expected call of SetRole.
+func (mr *MockUsersHandlerInterfaceMockRecorder) SetRole(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRole", reflect.TypeOf((*MockUsersHandlerInterface)(nil).SetRole), arg0)
+}
+
+// Unlock mocks base method.
+func (m *MockUsersHandlerInterface) Unlock(arg0 *gin.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Unlock", arg0)
+}
+
+// Unlock indicates an expected call of Unlock.
+func (mr *MockUsersHandlerInterfaceMockRecorder) Unlock(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unlock", reflect.TypeOf((*MockUsersHandlerInterface)(nil).Unlock), arg0)
+}
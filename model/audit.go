@@ -0,0 +1,71 @@
+package model
+
+import (
+	"strconv"
+	"time"
+)
+
+type AuditAction string
+
+const (
+	AuditSignIn        AuditAction = "auth.sign_in"
+	AuditSignUp        AuditAction = "auth.sign_up"
+	AuditRefresh       AuditAction = "auth.refresh"
+	AuditLogout        AuditAction = "auth.logout"
+	AuditLogoutAll     AuditAction = "auth.logout_all"
+	AuditRecover       AuditAction = "auth.recover"
+	AuditPasswordReset AuditAction = "auth.password_reset"
+	AuditAccessDenied  AuditAction = "access.denied"
+	AuditBookCreate    AuditAction = "book.create"
+	AuditBookUpdate    AuditAction = "book.update"
+	AuditBookDelete    AuditAction = "book.delete"
+	AuditBookRestore   AuditAction = "book.restore"
+	AuditBookRevert    AuditAction = "book.revert"
+)
+
+type AuditOutcome string
+
+const (
+	AuditSuccess AuditOutcome = "success"
+	AuditFailure AuditOutcome = "failure"
+	// AuditDenied is a request refused for lack of permission rather than
+	// for bad input or credentials.
+	AuditDenied AuditOutcome = "denied"
+)
+
+// AuditEvent records a security relevant request. Events are append only,
+// nothing updates or deletes them.
+type AuditEvent struct {
+	ID   uint64    `bson:"_id" json:"id"`
+	Time time.Time `bson:"time" json:"time"`
+	// ActorID is 0 if the caller is not known, such as a sign in with an
+	// unknown login. ActorLogin then holds the login that was tried.
+	ActorID    uint64       `bson:"actor_id,omitempty" json:"actor_id,omitempty"`
+	ActorLogin string       `bson:"actor_login,omitempty" json:"actor_login,omitempty"`
+	Action     AuditAction  `bson:"action" json:"action"`
+	Target     string       `bson:"target,omitempty" json:"target,omitempty"`
+	Outcome    AuditOutcome `bson:"outcome" json:"outcome"`
+	Reason     string       `bson:"reason,omitempty" json:"reason,omitempty"`
+	IP         string       `bson:"ip" json:"ip"`
+	UserAgent  string       `bson:"user_agent" json:"user_agent"`
+	RequestID  string       `bson:"request_id,omitempty" json:"request_id,omitempty"`
+}
+
+// AuditQuery filters audit events, zero fields match everything. Events
+// are returned newest first, BeforeID continues after the last event of
+// the previous page.
+type AuditQuery struct {
+	ActorID  uint64
+	Action   AuditAction
+	Outcome  AuditOutcome
+	Target   string
+	Since    time.Time
+	Until    time.Time
+	BeforeID uint64
+	Limit    int
+}
+
+// AuditTarget names the record an event is about, such as "book:42".
+func AuditTarget(kind string, ID uint64) string {
+	return kind + ":" + strconv.FormatUint(ID, 10)
+}
@@ -0,0 +1,68 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+const maxBioLength = 10000
+
+// Author is a person who contributed to books, independent of any account.
+type Author struct {
+	ID        uint64   `bson:"_id,omitempty" json:"id,omitempty"`
+	Name      string   `bson:"name" json:"name"`
+	Bio       string   `bson:"bio,omitempty" json:"bio,omitempty"`
+	BirthDate string   `bson:"birth_date,omitempty" json:"birth_date,omitempty"`
+	DeathDate string   `bson:"death_date,omitempty" json:"death_date,omitempty"`
+	Aliases   []string `bson:"aliases,omitempty" json:"aliases,omitempty"`
+	CreatedBy uint64   `bson:"created_by" json:"created_by"`
+}
+
+// Validate normalizes the author in place and returns a *ValidationError
+// listing every invalid field.
+func (a *Author) Validate() error {
+	errs := NewValidationError()
+
+	a.Name = strings.TrimSpace(a.Name)
+	a.Bio = strings.TrimSpace(a.Bio)
+	a.BirthDate = strings.TrimSpace(a.BirthDate)
+	a.DeathDate = strings.TrimSpace(a.DeathDate)
+
+	switch {
+	case a.Name == "":
+		errs.Add("name", "is required")
+	case len(a.Name) > maxShortFieldLength:
+		errs.Add("name", fmt.Sprintf("must be at most %d characters", maxShortFieldLength))
+	}
+	if len(a.Bio) > maxBioLength {
+		errs.Add("bio", fmt.Sprintf("must be at most %d characters", maxBioLength))
+	}
+
+	if a.BirthDate != "" && !validDate(a.BirthDate) {
+		errs.Add("birth_date", "must be YYYY, YYYY-MM or YYYY-MM-DD and not in the future")
+	}
+	if a.DeathDate != "" && !validDate(a.DeathDate) {
+		errs.Add("death_date", "must be YYYY, YYYY-MM or YYYY-MM-DD and not in the future")
+	}
+	// The layouts sort lexically in date order.
+	if a.BirthDate != "" && a.DeathDate != "" && a.DeathDate < a.BirthDate {
+		errs.Add("death_date", "must not be before birth_date")
+	}
+
+	aliases := make([]string, 0, len(a.Aliases))
+	for _, alias := range a.Aliases {
+		alias = strings.TrimSpace(alias)
+		if alias == "" || len(alias) > maxShortFieldLength {
+			errs.Add("aliases", fmt.Sprintf("entries must have 1 to %d characters", maxShortFieldLength))
+
+			continue
+		}
+		aliases = append(aliases, alias)
+	}
+	a.Aliases = aliases
+	if len(a.Aliases) == 0 {
+		a.Aliases = nil
+	}
+
+	return errs.Err()
+}
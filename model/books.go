@@ -1,7 +1,289 @@
 package model
 
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	maxTitleLength       = 500
+	maxDescriptionLength = 10000
+	maxShortFieldLength  = 200
+	maxPageCount         = 100000
+	maxGenres            = 20
+)
+
+var languagePattern = regexp.MustCompile(`^[a-z]{2,3}$`)
+
+// publicationDateLayouts are the accepted precisions of PublicationDate,
+// catalogs often only know the year or month.
+var publicationDateLayouts = []string{"2006-01-02", "2006-01", "2006"}
+
+type ContributorRole string
+
+const (
+	ContributorAuthor      ContributorRole = "author"
+	ContributorEditor      ContributorRole = "editor"
+	ContributorTranslator  ContributorRole = "translator"
+	ContributorIllustrator ContributorRole = "illustrator"
+)
+
+var ContributorRoles = []ContributorRole{
+	ContributorAuthor, ContributorEditor, ContributorTranslator, ContributorIllustrator,
+}
+
+// Contributor links a book to a person in the authors collection.
+type Contributor struct {
+	AuthorID uint64          `json:"author_id" bson:"author_id"`
+	Role     ContributorRole `json:"role" bson:"role"`
+}
+
 type Book struct {
-	ID       uint64 `bson:"_id,omitempty" json:"id,omitempty"`
-	Name     string `bson:"name" json:"name"`
-	AuthorID uint64 `json:"author_id" bson:"author_id"`
+	ID   uint64 `bson:"_id,omitempty" json:"id,omitempty"`
+	Name string `bson:"name" json:"name"`
+	// OwnerID is the account that created the record, it decides who may
+	// change it. The people who wrote the book are its Contributors.
+	OwnerID      uint64        `json:"owner_id" bson:"owner_id"`
+	Contributors []Contributor `json:"contributors,omitempty" bson:"contributors,omitempty"`
+	// CoAuthorIDs are accounts that may edit the book besides its owner.
+	CoAuthorIDs []uint64 `json:"co_author_ids,omitempty" bson:"co_author_ids,omitempty"`
+	LibraryID   uint64   `json:"library_id,omitempty" bson:"library_id,omitempty"`
+
+	// ISBN is always stored as ISBN-13, an ISBN-10 is converted on input.
+	ISBN        string `json:"isbn,omitempty" bson:"isbn,omitempty"`
+	Subtitle    string `json:"subtitle,omitempty" bson:"subtitle,omitempty"`
+	Description string `json:"description,omitempty" bson:"description,omitempty"`
+	// Language is an ISO 639 code such as "en".
+	Language        string   `json:"language,omitempty" bson:"language,omitempty"`
+	Publisher       string   `json:"publisher,omitempty" bson:"publisher,omitempty"`
+	PublicationDate string   `json:"publication_date,omitempty" bson:"publication_date,omitempty"`
+	PageCount       int      `json:"page_count,omitempty" bson:"page_count,omitempty"`
+	Genres          []string `json:"genres,omitempty" bson:"genres,omitempty"`
+	Subjects        []string `json:"subjects,omitempty" bson:"subjects,omitempty"`
+	Edition         string   `json:"edition,omitempty" bson:"edition,omitempty"`
+
+	// Version counts the changes to the book, an update only applies to the
+	// version it was based on.
+	Version uint64 `json:"version" bson:"version"`
+
+	// DeletedAt marks a book moved to the trash by DeletedBy. Trashed books
+	// are hidden until restored or purged.
+	DeletedAt *time.Time `json:"deleted_at,omitempty" bson:"deleted_at,omitempty"`
+	DeletedBy uint64     `json:"deleted_by,omitempty" bson:"deleted_by,omitempty"`
+}
+
+// Validate normalizes the book in place and returns a *ValidationError
+// listing every invalid field.
+func (b *Book) Validate() error {
+	errs := NewValidationError()
+
+	b.Name = strings.TrimSpace(b.Name)
+	b.Subtitle = strings.TrimSpace(b.Subtitle)
+	b.Description = strings.TrimSpace(b.Description)
+	b.Language = strings.ToLower(strings.TrimSpace(b.Language))
+	b.Publisher = strings.TrimSpace(b.Publisher)
+	b.PublicationDate = strings.TrimSpace(b.PublicationDate)
+	b.Edition = strings.TrimSpace(b.Edition)
+
+	switch {
+	case b.Name == "":
+		errs.Add("name", "is required")
+	case len(b.Name) > maxTitleLength:
+		errs.Add("name", fmt.Sprintf("must be at most %d characters", maxTitleLength))
+	}
+	if len(b.Subtitle) > maxTitleLength {
+		errs.Add("subtitle", fmt.Sprintf("must be at most %d characters", maxTitleLength))
+	}
+	if len(b.Description) > maxDescriptionLength {
+		errs.Add("description", fmt.Sprintf("must be at most %d characters", maxDescriptionLength))
+	}
+
+	if b.ISBN != "" {
+		isbn, err := NormalizeISBN(b.ISBN)
+		if err != nil {
+			errs.Add("isbn", err.Error())
+		}
+		b.ISBN = isbn
+	}
+
+	if b.Language != "" && !languagePattern.MatchString(b.Language) {
+		errs.Add("language", "must be an ISO 639 language code")
+	}
+	if len(b.Publisher) > maxShortFieldLength {
+		errs.Add("publisher", fmt.Sprintf("must be at most %d characters", maxShortFieldLength))
+	}
+	if len(b.Edition) > maxShortFieldLength {
+		errs.Add("edition", fmt.Sprintf("must be at most %d characters", maxShortFieldLength))
+	}
+
+	if b.PublicationDate != "" && !validDate(b.PublicationDate) {
+		errs.Add("publication_date", "must be YYYY, YYYY-MM or YYYY-MM-DD and not in the future")
+	}
+
+	if b.PageCount < 0 || b.PageCount > maxPageCount {
+		errs.Add("page_count", fmt.Sprintf("must be between 0 and %d", maxPageCount))
+	}
+
+	seen := map[Contributor]bool{}
+	for _, contributor := range b.Contributors {
+		switch {
+		case contributor.AuthorID == 0:
+			errs.Add("contributors", "author_id is required")
+		case !contributor.Role.Valid():
+			errs.Add("contributors", fmt.Sprintf("unknown role %q", contributor.Role))
+		case seen[contributor]:
+			errs.Add("contributors", "must not repeat an author with the same role")
+		}
+		seen[contributor] = true
+	}
+
+	var err string
+	if b.Genres, err = normalizeTerms(b.Genres); err != "" {
+		errs.Add("genres", err)
+	}
+	if b.Subjects, err = normalizeTerms(b.Subjects); err != "" {
+		errs.Add("subjects", err)
+	}
+
+	return errs.Err()
+}
+
+// ContributorIDs returns the distinct authors of the book.
+func (b *Book) ContributorIDs() []uint64 {
+	seen := map[uint64]bool{}
+	ids := []uint64{}
+	for _, contributor := range b.Contributors {
+		if !seen[contributor.AuthorID] {
+			seen[contributor.AuthorID] = true
+			ids = append(ids, contributor.AuthorID)
+		}
+	}
+
+	return ids
+}
+
+func (b *Book) HasContributor(authorID uint64) bool {
+	for _, contributor := range b.Contributors {
+		if contributor.AuthorID == authorID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// PublicID is the opaque id used in URLs.
+func (b Book) PublicID() string {
+	return EncodeID(b.ID)
+}
+
+// Slug is a readable URL reference to the book, the title followed by the
+// public id. Only the id part is used to look the book up.
+func (b Book) Slug() string {
+	if name := Slugify(b.Name); name != "" {
+		return name + "-" + b.PublicID()
+	}
+
+	return b.PublicID()
+}
+
+// ETag is the entity tag of the book version.
+func (b Book) ETag() string {
+	return `"` + strconv.FormatUint(b.Version, 10) + `"`
+}
+
+// MarshalJSON adds public_id and slug. The numeric id is kept for existing
+// clients, but generated ids exceed the integers a JavaScript number holds
+// exactly, so new clients should use public_id.
+func (b Book) MarshalJSON() ([]byte, error) {
+	type book Book
+	out := struct {
+		book
+		PublicID string `json:"public_id,omitempty"`
+		Slug     string `json:"slug,omitempty"`
+	}{book: book(b)}
+	if b.ID != 0 {
+		out.PublicID = b.PublicID()
+		out.Slug = b.Slug()
+	}
+
+	return json.Marshal(out)
+}
+
+func (r ContributorRole) Valid() bool {
+	for _, role := range ContributorRoles {
+		if r == role {
+			return true
+		}
+	}
+
+	return false
+}
+
+// validDate accepts the publicationDateLayouts and rejects future dates.
+func validDate(s string) bool {
+	for _, layout := range publicationDateLayouts {
+		date, err := time.Parse(layout, s)
+		if err == nil {
+			return !date.After(time.Now())
+		}
+	}
+
+	return false
+}
+
+// normalizeTerms trims, lowercases and deduplicates genres or subjects.
+func normalizeTerms(terms []string) ([]string, string) {
+	if len(terms) > maxGenres {
+		return terms, fmt.Sprintf("must have at most %d entries", maxGenres)
+	}
+
+	seen := map[string]bool{}
+	result := make([]string, 0, len(terms))
+	for _, term := range terms {
+		term = strings.ToLower(strings.TrimSpace(term))
+		if term == "" || len(term) > maxShortFieldLength {
+			return terms, fmt.Sprintf("entries must have 1 to %d characters", maxShortFieldLength)
+		}
+		if !seen[term] {
+			seen[term] = true
+			result = append(result, term)
+		}
+	}
+	if len(result) == 0 {
+		return nil, ""
+	}
+
+	return result, ""
+}
+
+const (
+	BookSortID   = "id"
+	BookSortName = "name"
+)
+
+// BookQuery filters and pages the book catalog. Cursor is the opaque
+// NextCursor of the previous page.
+type BookQuery struct {
+	Text      string
+	AuthorID  uint64
+	OwnerID   uint64
+	ISBN      string
+	Language  string
+	Genre     string
+	Sort      string
+	Desc      bool
+	Limit     int
+	Cursor    string
+	WithTotal bool
+}
+
+type BookPage struct {
+	Items      []Book `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	Total      *int   `json:"total,omitempty"`
 }
@@ -0,0 +1,40 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBookValidateNormalizes(t *testing.T) {
+	book := Book{
+		Name:            " Dune ",
+		ISBN:            "0-441-17271-7",
+		Language:        "EN",
+		PublicationDate: "1965-08",
+		Genres:          []string{"Science Fiction", "science fiction ", "Adventure"},
+	}
+
+	assert.NoError(t, book.Validate())
+	assert.Equal(t, "Dune", book.Name)
+	assert.Equal(t, "9780441172719", book.ISBN)
+	assert.Equal(t, "en", book.Language)
+	assert.Equal(t, []string{"science fiction", "adventure"}, book.Genres)
+}
+
+func TestBookValidateReportsFields(t *testing.T) {
+	book := Book{
+		ISBN:            "978-0-306-40615-8",
+		Language:        "english",
+		PublicationDate: "08/1965",
+		PageCount:       -1,
+	}
+
+	err := book.Validate()
+	validationErr, ok := err.(*ValidationError)
+	assert.True(t, ok)
+	assert.Equal(t, 400, validationErr.Status())
+	for _, field := range []string{"name", "isbn", "language", "publication_date", "page_count"} {
+		assert.Contains(t, validationErr.Fields, field)
+	}
+}
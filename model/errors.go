@@ -8,6 +8,16 @@ var (
 	ErrUnauthorized        = NewError(http.StatusUnauthorized, "user unauthorized")
 	ErrRefreshExpired      = NewError(http.StatusUnauthorized, "refresh")
 	ErrForbidden           = NewError(http.StatusForbidden, "forbidden")
+	ErrNotFound            = NewError(http.StatusNotFound, "record not found")
+	ErrUserLocked          = NewError(http.StatusForbidden, "user locked")
+	ErrPasswordReset       = NewError(http.StatusForbidden, "password reset required")
+	ErrDuplicateISBN       = NewError(http.StatusConflict, "a book with this isbn already exists")
+	ErrAuthorInUse         = NewError(http.StatusConflict, "author is a contributor of books")
+	ErrPreconditionFailed  = NewError(http.StatusPreconditionFailed, "the record was changed, fetch it again")
+	ErrMissingIfMatch      = NewError(http.StatusPreconditionRequired, "If-Match header with the record ETag is required")
+	ErrUnsupportedPatch    = NewError(http.StatusUnsupportedMediaType, "use application/merge-patch+json or application/json-patch+json")
+	ErrPatchTestFailed     = NewError(http.StatusConflict, "a test operation of the patch failed")
+	ErrPatchNotApplicable  = NewError(http.StatusUnprocessableEntity, "the patch cannot be applied to the record")
 )
 
 type Error interface {
@@ -34,3 +44,35 @@ func NewError(code int, message string) Error {
 		Message: message,
 	}
 }
+
+// ValidationError reports every invalid field of a request body, keyed by its
+// JSON name.
+type ValidationError struct {
+	StatusError
+	Fields map[string]string `json:"fields"`
+}
+
+func NewValidationError() *ValidationError {
+	return &ValidationError{
+		StatusError: StatusError{
+			Code:    http.StatusBadRequest,
+			Message: "validation failed",
+		},
+		Fields: map[string]string{},
+	}
+}
+
+func (ve *ValidationError) Add(field, message string) {
+	if _, ok := ve.Fields[field]; !ok {
+		ve.Fields[field] = message
+	}
+}
+
+// Err returns nil when no field was invalid.
+func (ve *ValidationError) Err() error {
+	if len(ve.Fields) == 0 {
+		return nil
+	}
+
+	return ve
+}
@@ -0,0 +1,104 @@
+package model
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// PublicIDLength is the length of an opaque id, 64 bits in base32.
+const PublicIDLength = 13
+
+const maxSlugLength = 60
+
+// crockford is the lower case Crockford base32 alphabet, it leaves out
+// letters that are easily mistaken for digits.
+const crockford = "0123456789abcdefghjkmnpqrstvwxyz"
+
+var ErrInvalidID = errors.New("invalid id")
+
+// EncodeID returns the opaque public form of an id.
+func EncodeID(ID uint64) string {
+	var buf [PublicIDLength]byte
+	for i := PublicIDLength - 1; i >= 0; i-- {
+		buf[i] = crockford[ID&31]
+		ID >>= 5
+	}
+
+	return string(buf[:])
+}
+
+// DecodeID parses an id encoded by EncodeID. Upper case and the usual
+// misreadings of i, l and o are accepted.
+func DecodeID(s string) (uint64, error) {
+	if len(s) != PublicIDLength {
+		return 0, ErrInvalidID
+	}
+
+	var ID uint64
+	for i, r := range strings.ToLower(s) {
+		switch r {
+		case 'i', 'l':
+			r = '1'
+		case 'o':
+			r = '0'
+		}
+		n := strings.IndexRune(crockford, r)
+		// The first character only carries the top 4 bits.
+		if n < 0 || (i == 0 && n > 15) {
+			return 0, ErrInvalidID
+		}
+		ID = ID<<5 | uint64(n)
+	}
+
+	return ID, nil
+}
+
+// ParseRef reads the id from a path parameter, which is either a numeric id,
+// an opaque id or a slug ending in one such as "dune-0c4f1g2h3j4k5".
+// Numeric ids of 13 digits are read as opaque, no stored id is that short
+// and that long at the same time.
+func ParseRef(ref string) (uint64, error) {
+	if len(ref) > PublicIDLength && ref[len(ref)-PublicIDLength-1] == '-' {
+		return DecodeID(ref[len(ref)-PublicIDLength:])
+	}
+	if len(ref) == PublicIDLength {
+		return DecodeID(ref)
+	}
+
+	ID, err := strconv.ParseUint(ref, 10, 64)
+	if err != nil || ID == 0 {
+		return 0, ErrInvalidID
+	}
+
+	return ID, nil
+}
+
+// Slugify turns a title into lower case ASCII words joined by hyphens,
+// dropping accents and punctuation.
+func Slugify(s string) string {
+	var slug strings.Builder
+	hyphen := false
+	for _, r := range norm.NFKD.String(s) {
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			if hyphen && slug.Len() > 0 {
+				slug.WriteByte('-')
+			}
+			hyphen = false
+			slug.WriteRune(unicode.ToLower(r))
+		case unicode.Is(unicode.Mn, r):
+			// combining accent split off by NFKD
+		default:
+			hyphen = true
+		}
+		if slug.Len() >= maxSlugLength {
+			break
+		}
+	}
+
+	return slug.String()
+}
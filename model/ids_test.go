@@ -0,0 +1,42 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeID(t *testing.T) {
+	for _, ID := range []uint64{1, 42, 1 << 40, 1<<64 - 1} {
+		encoded := EncodeID(ID)
+		assert.Len(t, encoded, PublicIDLength)
+
+		decoded, err := DecodeID(encoded)
+		assert.NoError(t, err)
+		assert.Equal(t, ID, decoded)
+	}
+
+	_, err := DecodeID("zzzzzzzzzzzzz")
+	assert.ErrorIs(t, err, ErrInvalidID)
+}
+
+func TestParseRef(t *testing.T) {
+	book := Book{ID: 123456789012345678, Name: "Dune Messiah"}
+
+	for _, ref := range []string{"123456789012345678", book.PublicID(), book.Slug()} {
+		ID, err := ParseRef(ref)
+		assert.NoError(t, err, ref)
+		assert.Equal(t, book.ID, ID, ref)
+	}
+
+	for _, ref := range []string{"", "0", "dune", "-1"} {
+		_, err := ParseRef(ref)
+		assert.ErrorIs(t, err, ErrInvalidID, ref)
+	}
+}
+
+func TestSlugify(t *testing.T) {
+	assert.Equal(t, "les-miserables", Slugify("Les Misérables"))
+	assert.Equal(t, "dune-messiah", Slugify("  Dune: Messiah! "))
+	assert.Equal(t, "", Slugify("???"))
+}
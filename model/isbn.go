@@ -0,0 +1,85 @@
+package model
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	ErrISBNLength   = errors.New("isbn must have 10 or 13 digits")
+	ErrISBNChecksum = errors.New("isbn checksum is invalid")
+	ErrISBNPrefix   = errors.New("isbn-13 must start with 978 or 979")
+)
+
+// NormalizeISBN validates an ISBN-10 or ISBN-13, ignoring hyphens and
+// spaces, and returns it as a bare ISBN-13. Other EAN-13 codes, such as the
+// 977 of periodicals, have valid checksums but are not ISBNs.
+func NormalizeISBN(s string) (string, error) {
+	isbn := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(s))
+
+	switch len(isbn) {
+	case 10:
+		if !validISBN10(isbn) {
+			return "", ErrISBNChecksum
+		}
+		isbn13 := "978" + isbn[:9]
+
+		return isbn13 + string(isbn13CheckDigit(isbn13)), nil
+	case 13:
+		if !isDigits(isbn) || isbn13CheckDigit(isbn[:12]) != isbn[12] {
+			return "", ErrISBNChecksum
+		}
+		if prefix := isbn[:3]; prefix != "978" && prefix != "979" {
+			return "", ErrISBNPrefix
+		}
+
+		return isbn, nil
+	}
+
+	return "", ErrISBNLength
+}
+
+func validISBN10(isbn string) bool {
+	if !isDigits(isbn[:9]) {
+		return false
+	}
+
+	sum := 0
+	for i := 0; i < 9; i++ {
+		sum += int(isbn[i]-'0') * (10 - i)
+	}
+
+	switch check := isbn[9]; {
+	case check == 'X':
+		sum += 10
+	case check >= '0' && check <= '9':
+		sum += int(check - '0')
+	default:
+		return false
+	}
+
+	return sum%11 == 0
+}
+
+func isbn13CheckDigit(first12 string) byte {
+	sum := 0
+	for i := 0; i < 12; i++ {
+		weight := 1
+		if i%2 == 1 {
+			weight = 3
+		}
+		sum += int(first12[i]-'0') * weight
+	}
+
+	return byte('0' + (10-sum%10)%10)
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,31 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeISBN(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		err  error
+	}{
+		{"978-0-306-40615-7", "9780306406157", nil},
+		{"0-306-40615-2", "9780306406157", nil},
+		{"0 8044 2957 X", "9780804429573", nil},
+		{"080442957x", "9780804429573", nil},
+		{"978-0-306-40615-8", "", ErrISBNChecksum},
+		{"0-306-40615-3", "", ErrISBNChecksum},
+		{"03064061X2", "", ErrISBNChecksum},
+		{"977-1234-567-00-3", "", ErrISBNPrefix},
+		{"12345", "", ErrISBNLength},
+	}
+
+	for _, tt := range tests {
+		got, err := NormalizeISBN(tt.in)
+		assert.Equal(t, tt.want, got, tt.in)
+		assert.Equal(t, tt.err, err, tt.in)
+	}
+}
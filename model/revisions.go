@@ -0,0 +1,95 @@
+package model
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"time"
+)
+
+type RevisionAction string
+
+const (
+	RevisionCreate  RevisionAction = "create"
+	RevisionUpdate  RevisionAction = "update"
+	RevisionDelete  RevisionAction = "delete"
+	RevisionRestore RevisionAction = "restore"
+	// RevisionPurge is the last revision of a book removed from the trash,
+	// its snapshot keeps only the id.
+	RevisionPurge RevisionAction = "purge"
+)
+
+// BookRevision is the immutable record of one change to a book. Revision
+// is the Version of the book after the change.
+type BookRevision struct {
+	ID        uint64         `bson:"_id" json:"id"`
+	BookID    uint64         `bson:"book_id" json:"book_id"`
+	Revision  uint64         `bson:"revision" json:"revision"`
+	Action    RevisionAction `bson:"action" json:"action"`
+	ActorID   uint64         `bson:"actor_id" json:"actor_id"`
+	CreatedAt time.Time      `bson:"created_at" json:"created_at"`
+	// Changed names the fields the change touched, by their JSON name.
+	Changed  []string `bson:"changed" json:"changed"`
+	Snapshot Book     `bson:"snapshot" json:"snapshot"`
+}
+
+// FieldChange is a field that differs between two versions of a book.
+type FieldChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+// untrackedFields change with every write or follow from other fields.
+var untrackedFields = map[string]bool{
+	"id":        true,
+	"version":   true,
+	"public_id": true,
+	"slug":      true,
+}
+
+// DiffBooks lists the fields that differ between two versions of a book,
+// sorted by name. Absent fields are reported as nil.
+func DiffBooks(from, to Book) []FieldChange {
+	before, after := bookFields(from), bookFields(to)
+	names := map[string]bool{}
+	for name := range before {
+		names[name] = true
+	}
+	for name := range after {
+		names[name] = true
+	}
+
+	changes := []FieldChange{}
+	for name := range names {
+		if !untrackedFields[name] && !reflect.DeepEqual(before[name], after[name]) {
+			changes = append(changes, FieldChange{Field: name, From: before[name], To: after[name]})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+
+	return changes
+}
+
+// ChangedFields names the fields DiffBooks reports.
+func ChangedFields(from, to Book) []string {
+	changes := DiffBooks(from, to)
+	fields := make([]string, 0, len(changes))
+	for _, change := range changes {
+		fields = append(fields, change.Field)
+	}
+
+	return fields
+}
+
+// bookFields is the JSON form of the book as a map, so that versions compare
+// the way clients see them.
+func bookFields(book Book) map[string]interface{} {
+	fields := map[string]interface{}{}
+	data, err := json.Marshal(book)
+	if err == nil {
+		_ = json.Unmarshal(data, &fields)
+	}
+
+	return fields
+}
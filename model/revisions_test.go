@@ -0,0 +1,19 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffBooks(t *testing.T) {
+	from := Book{ID: 1, Version: 1, Name: "Dune", Genres: []string{"science fiction"}}
+	to := Book{ID: 1, Version: 2, Name: "Dune", Subtitle: "Book One"}
+
+	assert.Equal(t, []FieldChange{
+		{Field: "genres", From: []interface{}{"science fiction"}},
+		{Field: "subtitle", To: "Book One"},
+	}, DiffBooks(from, to))
+	assert.Empty(t, DiffBooks(from, from))
+	assert.Equal(t, []string{"name", "owner_id"}, ChangedFields(Book{}, Book{ID: 2, Version: 1, Name: "Emma", OwnerID: 3}))
+}
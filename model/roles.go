@@ -0,0 +1,49 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Role string
+
+const (
+	RoleReader    Role = "reader"
+	RoleAuthor    Role = "author"
+	RoleLibrarian Role = "librarian"
+	RoleAdmin     Role = "admin"
+
+	// DefaultRole is given to every account created through sign up, so new
+	// accounts can keep publishing books.
+	DefaultRole = RoleAuthor
+)
+
+var Roles = []Role{RoleReader, RoleAuthor, RoleLibrarian, RoleAdmin}
+
+// ParseRole parses a role case-insensitively. An empty string is the default
+// role.
+func ParseRole(s string) (Role, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return DefaultRole, nil
+	}
+
+	for _, role := range Roles {
+		if string(role) == s {
+			return role, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown role %q", s)
+}
+
+// Is reports whether r is one of roles.
+func (r Role) Is(roles ...Role) bool {
+	for _, role := range roles {
+		if r == role {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,14 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEffectiveRole(t *testing.T) {
+	assert.Equal(t, RoleAuthor, User{Role: "Author"}.EffectiveRole())
+	assert.Equal(t, DefaultRole, User{}.EffectiveRole())
+	assert.Equal(t, RoleReader, User{Role: "superuser"}.EffectiveRole())
+	assert.Equal(t, RoleAdmin, User{Role: RoleAdmin}.EffectiveRole())
+}
@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// Session is a signed in device. Its ID is the refresh token family id, so
+// every rotation of the refresh token keeps the same session.
+type Session struct {
+	ID            string     `bson:"_id" json:"id"`
+	UserID        uint64     `bson:"user_id" json:"user_id"`
+	UserAgent     string     `bson:"user_agent" json:"user_agent"`
+	IP            string     `bson:"ip" json:"ip"`
+	CreatedAt     time.Time  `bson:"created_at" json:"created_at"`
+	LastRefreshAt time.Time  `bson:"last_refresh_at" json:"last_refresh_at"`
+	ExpiresAt     time.Time  `bson:"expires_at" json:"expires_at"`
+	RevokedAt     *time.Time `bson:"revoked_at,omitempty" json:"-"`
+	Current       bool       `bson:"-" json:"current"`
+}
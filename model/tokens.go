@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// RefreshToken is the server side record of an issued refresh token. All
+// tokens rotated from the same sign in share a FamilyID.
+type RefreshToken struct {
+	ID        string     `bson:"_id" json:"id"`
+	UserID    uint64     `bson:"user_id" json:"user_id"`
+	FamilyID  string     `bson:"family_id" json:"family_id"`
+	IssuedAt  time.Time  `bson:"issued_at" json:"issued_at"`
+	ExpiresAt time.Time  `bson:"expires_at" json:"expires_at"`
+	UsedAt    *time.Time `bson:"used_at,omitempty" json:"used_at,omitempty"`
+	Revoked   bool       `bson:"revoked" json:"revoked"`
+}
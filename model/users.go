@@ -3,7 +3,31 @@ package model
 type User struct {
 	ID            uint64 `bson:"_id,omitempty" json:"id,omitempty"`
 	Login         string `bson:"login" json:"login"`
-	Password      string `bson:"password" json:"password"`
-	Role          string `bson:"role" json:"role"`
-	RecoveryToken string `bson:"recoveryToken" json:"recoveryToken"`
+	Password      string `bson:"password" json:"-"`
+	Role          Role   `bson:"role" json:"role"`
+	RecoveryToken string `bson:"recoveryToken" json:"-"`
+	LibraryID     uint64 `bson:"library_id,omitempty" json:"library_id,omitempty"`
+	Locked        bool   `bson:"locked" json:"locked"`
+	// PasswordResetRequired blocks sign in until a new password is set
+	// through the recovery token.
+	PasswordResetRequired bool `bson:"password_reset_required" json:"password_reset_required"`
+}
+
+// Credentials is the sign in and sign up request body. It is kept apart from
+// User so that clients can never set other user fields.
+type Credentials struct {
+	Login    string `json:"login"`
+	Password string `json:"password"`
+}
+
+// EffectiveRole is the role the user acts with. Records stored before roles
+// were enforced may hold a differently cased or unknown role, the latter
+// gets the least privileges.
+func (u User) EffectiveRole() Role {
+	role, err := ParseRole(string(u.Role))
+	if err != nil {
+		return RoleReader
+	}
+
+	return role
 }
@@ -0,0 +1,15 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserJSONOmitsSecrets(t *testing.T) {
+	data, err := json.Marshal(User{ID: 1, Login: "reader", Password: "hash", RecoveryToken: "token"})
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "hash")
+	assert.NotContains(t, string(data), "token")
+}
@@ -0,0 +1,48 @@
+package policy
+
+import "bookService/model"
+
+const KindAuthor = "author"
+
+type Author struct {
+	model.Author
+}
+
+func (Author) Kind() string {
+	return KindAuthor
+}
+
+var authorRules = []Rule{
+	{
+		Name:    "anyone may read",
+		Kind:    KindAuthor,
+		Actions: []Action{ActionRead},
+		Allow: func(Subject, Resource) bool {
+			return true
+		},
+	},
+	{
+		Name:    "authors and librarians may create",
+		Kind:    KindAuthor,
+		Actions: []Action{ActionCreate},
+		Allow: func(subject Subject, _ Resource) bool {
+			return subject.Role.Is(model.RoleAuthor, model.RoleLibrarian)
+		},
+	},
+	{
+		Name:    "creator may edit",
+		Kind:    KindAuthor,
+		Actions: []Action{ActionUpdate},
+		Allow: On(func(subject Subject, author Author) bool {
+			return author.CreatedBy == subject.ID
+		}),
+	},
+	{
+		Name:    "librarian may edit and delete",
+		Kind:    KindAuthor,
+		Actions: []Action{ActionUpdate, ActionDelete},
+		Allow: func(subject Subject, _ Resource) bool {
+			return subject.Role == model.RoleLibrarian
+		},
+	},
+}
@@ -0,0 +1,63 @@
+package policy
+
+import "bookService/model"
+
+const KindBook = "book"
+
+type Book struct {
+	model.Book
+}
+
+func (Book) Kind() string {
+	return KindBook
+}
+
+var bookRules = []Rule{
+	{
+		Name:    "anyone may read",
+		Kind:    KindBook,
+		Actions: []Action{ActionRead},
+		Allow: func(Subject, Resource) bool {
+			return true
+		},
+	},
+	{
+		Name:    "authors and librarians may create",
+		Kind:    KindBook,
+		Actions: []Action{ActionCreate},
+		Allow: func(subject Subject, _ Resource) bool {
+			return subject.Role.Is(model.RoleAuthor, model.RoleLibrarian)
+		},
+	},
+	{
+		Name:    "owner may edit, share, delete and restore",
+		Kind:    KindBook,
+		Actions: []Action{ActionUpdate, ActionShare, ActionDelete, ActionRestore},
+		Allow: On(func(subject Subject, book Book) bool {
+			return book.OwnerID == subject.ID
+		}),
+	},
+	{
+		Name:    "co-author may edit",
+		Kind:    KindBook,
+		Actions: []Action{ActionUpdate},
+		Allow: On(func(subject Subject, book Book) bool {
+			for _, id := range book.CoAuthorIDs {
+				if id == subject.ID {
+					return true
+				}
+			}
+
+			return false
+		}),
+	},
+	{
+		Name:    "librarian may edit any book in their library",
+		Kind:    KindBook,
+		Actions: []Action{ActionUpdate},
+		Allow: On(func(subject Subject, book Book) bool {
+			return subject.Role == model.RoleLibrarian &&
+				subject.LibraryID != 0 && book.LibraryID == subject.LibraryID
+		}),
+	},
+}
@@ -0,0 +1,106 @@
+package policy
+
+import (
+	"bookService/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthorizeBook(t *testing.T) {
+	book := Book{Book: model.Book{ID: 1, OwnerID: 10, CoAuthorIDs: []uint64{11}, LibraryID: 7}}
+
+	owner := Subject{ID: 10, Role: model.RoleAuthor}
+	coAuthor := Subject{ID: 11, Role: model.RoleAuthor}
+	stranger := Subject{ID: 12, Role: model.RoleAuthor}
+	reader := Subject{ID: 13, Role: model.RoleReader}
+	librarian := Subject{ID: 14, Role: model.RoleLibrarian, LibraryID: 7}
+	otherLibrarian := Subject{ID: 15, Role: model.RoleLibrarian, LibraryID: 8}
+	admin := Subject{ID: 16, Role: model.RoleAdmin}
+
+	tests := []struct {
+		name    string
+		subject Subject
+		action  Action
+		allowed bool
+	}{
+		{"reader may read", reader, ActionRead, true},
+		{"reader may not create", reader, ActionCreate, false},
+		{"author may create", stranger, ActionCreate, true},
+		{"librarian may create", librarian, ActionCreate, true},
+		{"owner may update", owner, ActionUpdate, true},
+		{"owner may share", owner, ActionShare, true},
+		{"owner may delete", owner, ActionDelete, true},
+		{"co-author may update", coAuthor, ActionUpdate, true},
+		{"co-author may not share", coAuthor, ActionShare, false},
+		{"co-author may not delete", coAuthor, ActionDelete, false},
+		{"stranger may not update", stranger, ActionUpdate, false},
+		{"stranger may not delete", stranger, ActionDelete, false},
+		{"librarian may update in their library", librarian, ActionUpdate, true},
+		{"librarian may not delete in their library", librarian, ActionDelete, false},
+		{"librarian may not update in another library", otherLibrarian, ActionUpdate, false},
+		{"admin may update", admin, ActionUpdate, true},
+		{"admin may delete", admin, ActionDelete, true},
+		{"owner may restore", owner, ActionRestore, true},
+		{"co-author may not restore", coAuthor, ActionRestore, false},
+		{"admin may restore", admin, ActionRestore, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Authorize(tt.subject, tt.action, book)
+			if tt.allowed {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrDenied)
+			}
+		})
+	}
+}
+
+func TestLibrarianWithoutLibrary(t *testing.T) {
+	book := Book{Book: model.Book{OwnerID: 10}}
+	librarian := Subject{ID: 14, Role: model.RoleLibrarian}
+
+	assert.ErrorIs(t, Authorize(librarian, ActionUpdate, book), ErrDenied)
+}
+
+type shelf struct{}
+
+func (shelf) Kind() string {
+	return "shelf"
+}
+
+func TestUnknownKindOnlyAllowsAdmin(t *testing.T) {
+	assert.NoError(t, Authorize(Subject{Role: model.RoleAdmin}, ActionDelete, shelf{}))
+	assert.ErrorIs(t, Authorize(Subject{Role: model.RoleAuthor}, ActionRead, shelf{}), ErrDenied)
+}
+
+func TestAuthorizeAuthor(t *testing.T) {
+	author := Author{Author: model.Author{ID: 1, Name: "Frank Herbert", CreatedBy: 10}}
+
+	tests := []struct {
+		name    string
+		subject Subject
+		action  Action
+		allowed bool
+	}{
+		{"reader may read", Subject{ID: 13, Role: model.RoleReader}, ActionRead, true},
+		{"reader may not create", Subject{ID: 13, Role: model.RoleReader}, ActionCreate, false},
+		{"creator may update", Subject{ID: 10, Role: model.RoleAuthor}, ActionUpdate, true},
+		{"creator may not delete", Subject{ID: 10, Role: model.RoleAuthor}, ActionDelete, false},
+		{"other author may not update", Subject{ID: 12, Role: model.RoleAuthor}, ActionUpdate, false},
+		{"librarian may delete", Subject{ID: 14, Role: model.RoleLibrarian}, ActionDelete, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Authorize(tt.subject, tt.action, author)
+			if tt.allowed {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrDenied)
+			}
+		})
+	}
+}
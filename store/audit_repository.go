@@ -0,0 +1,74 @@
+package store
+
+import (
+	"bookService/model"
+	"context"
+
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+const collectionAuditEvents = "audit_events"
+
+type AuditRepository struct {
+	store *MongoStore
+}
+
+func NewAuditRepository(store *MongoStore) *AuditRepository {
+	return &AuditRepository{
+		store: store,
+	}
+}
+
+func (r *AuditRepository) Insert(ctx context.Context, event model.AuditEvent) error {
+	event.ID = r.store.IDs.NewID()
+	_, err := r.store.conn.Collection(collectionAuditEvents).InsertOne(ctx, event)
+	if err != nil {
+		logError(ctx, "Insert InsertOne", err)
+	}
+
+	return mongoErr(err)
+}
+
+// Search sorts by id, which follows the time as ids are snowflakes.
+func (r *AuditRepository) Search(ctx context.Context, query model.AuditQuery) ([]model.AuditEvent, error) {
+	filter := obj{}
+	if query.ActorID != 0 {
+		filter["actor_id"] = query.ActorID
+	}
+	if query.Action != "" {
+		filter["action"] = query.Action
+	}
+	if query.Outcome != "" {
+		filter["outcome"] = query.Outcome
+	}
+	if query.Target != "" {
+		filter["target"] = query.Target
+	}
+	if query.BeforeID != 0 {
+		filter["_id"] = obj{"$lt": query.BeforeID}
+	}
+	period := obj{}
+	if !query.Since.IsZero() {
+		period["$gte"] = query.Since
+	}
+	if !query.Until.IsZero() {
+		period["$lt"] = query.Until
+	}
+	if len(period) > 0 {
+		filter["time"] = period
+	}
+
+	results := []model.AuditEvent{}
+	cursor, err := r.store.conn.Collection(collectionAuditEvents).Find(ctx, filter,
+		options.Find().SetSort(keys("-_id")).SetLimit(int64(query.Limit)))
+	if err == nil {
+		err = cursor.All(ctx, &results)
+	}
+	if err != nil {
+		logError(ctx, "Search Find", err)
+
+		return nil, mongoErr(err)
+	}
+
+	return results, nil
+}
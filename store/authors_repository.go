@@ -0,0 +1,122 @@
+package store
+
+import (
+	"bookService/model"
+	"context"
+	"regexp"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+const (
+	collectionAuthors = "authors"
+)
+
+type (
+	AuthorsRepository struct {
+		store          *MongoStore
+		collectionName string
+	}
+)
+
+func NewAuthorsRepository(store *MongoStore) *AuthorsRepository {
+	return &AuthorsRepository{
+		store:          store,
+		collectionName: collectionAuthors,
+	}
+}
+
+// Search pages through the authors whose name or alias contains name,
+// ordered by id.
+func (r *AuthorsRepository) Search(ctx context.Context, name string, offset, limit int) ([]model.Author, int, error) {
+	filter := obj{}
+	if name != "" {
+		pattern := bson.Regex{Pattern: regexp.QuoteMeta(name), Options: "i"}
+		filter["$or"] = []obj{{"name": pattern}, {"aliases": pattern}}
+	}
+
+	collection := r.store.conn.Collection(collectionAuthors)
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		logError(ctx, "Search CountDocuments", err)
+
+		return nil, 0, mongoErr(err)
+	}
+
+	results := []model.Author{}
+	cursor, err := collection.Find(ctx, filter,
+		options.Find().SetSort(keys("_id")).SetSkip(int64(offset)).SetLimit(int64(limit)))
+	if err == nil {
+		err = cursor.All(ctx, &results)
+	}
+	if err != nil {
+		logError(ctx, "Search Find", err)
+
+		return nil, 0, mongoErr(err)
+	}
+
+	return results, int(total), nil
+}
+
+func (r *AuthorsRepository) Find(ctx context.Context, authorID uint64) (model.Author, error) {
+	result := model.Author{}
+	err := r.store.conn.Collection(collectionAuthors).FindOne(ctx, obj{"_id": authorID}).Decode(&result)
+	if err != nil {
+		logError(ctx, "Find FindOne", err)
+
+		return model.Author{}, mongoErr(err)
+	}
+
+	return result, nil
+}
+
+// Exist reports whether every id belongs to a stored author.
+func (r *AuthorsRepository) Exist(ctx context.Context, IDs []uint64) (bool, error) {
+	if len(IDs) == 0 {
+		return true, nil
+	}
+
+	n, err := r.store.conn.Collection(collectionAuthors).CountDocuments(ctx, obj{"_id": obj{"$in": IDs}})
+	if err != nil {
+		logError(ctx, "Exist CountDocuments", err)
+
+		return false, mongoErr(err)
+	}
+
+	return int(n) == len(IDs), nil
+}
+
+func (r *AuthorsRepository) Insert(ctx context.Context, item model.Author) (model.Author, error) {
+	item.ID = r.store.IDs.NewID()
+	_, err := r.store.conn.Collection(collectionAuthors).InsertOne(ctx, item)
+	if err != nil {
+		logError(ctx, "Insert InsertOne", err)
+
+		return model.Author{}, mongoErr(err)
+	}
+
+	return item, nil
+}
+
+func (r *AuthorsRepository) Update(ctx context.Context, item model.Author) error {
+	result, err := r.store.conn.Collection(collectionAuthors).ReplaceOne(ctx, obj{"_id": item.ID}, item)
+	if err != nil {
+		logError(ctx, "Update ReplaceOne", err)
+
+		return mongoErr(err)
+	}
+
+	return matched(result.MatchedCount)
+}
+
+func (r *AuthorsRepository) Delete(ctx context.Context, ID uint64) error {
+	result, err := r.store.conn.Collection(collectionAuthors).DeleteOne(ctx, obj{"_id": ID})
+	if err != nil {
+		logError(ctx, "Delete DeleteOne", err)
+
+		return mongoErr(err)
+	}
+
+	return matched(result.DeletedCount)
+}
@@ -0,0 +1,41 @@
+package store
+
+import (
+	"bookService/model"
+	"context"
+)
+
+// maxPatchAttempts bounds how often a patch is reapplied when other writes
+// keep changing the book between the read and the write.
+const maxPatchAttempts = 5
+
+// BookPatch computes the new state of a book from the stored one.
+type BookPatch func(current model.Book) (model.Book, error)
+
+// patchBook reads the book, applies patch and writes the result only if the
+// book is still at the version that was read. On a conflict the patch is
+// applied again to the fresh book, unless version pins it, then it fails
+// with ErrVersionConflict.
+func patchBook(ctx context.Context, books BookStore, ID, version, actorID uint64, patch BookPatch) (model.Book, error) {
+	for attempt := 1; ; attempt++ {
+		current, err := books.Find(ctx, ID)
+		if err != nil {
+			return model.Book{}, err
+		}
+		if version != 0 && current.Version != version {
+			return model.Book{}, ErrVersionConflict
+		}
+
+		item, err := patch(current)
+		if err != nil {
+			return model.Book{}, err
+		}
+		item.ID = current.ID
+		item.Version = current.Version
+
+		updated, err := books.Update(ctx, item, actorID)
+		if err != ErrVersionConflict || version != 0 || attempt == maxPatchAttempts {
+			return updated, err
+		}
+	}
+}
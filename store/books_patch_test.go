@@ -0,0 +1,39 @@
+package store
+
+import (
+	"bookService/model"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPatchBookRetriesOnConflict(t *testing.T) {
+	ctx := context.Background()
+	books := NewMemoryStore().Books()
+	book, err := books.Insert(ctx, model.Book{Name: "Dune"}, 1)
+	assert.NoError(t, err)
+
+	attempts := 0
+	patched, err := books.Patch(ctx, book.ID, 0, 1, func(current model.Book) (model.Book, error) {
+		attempts++
+		if attempts == 1 {
+			// Another writer gets in between the read and the write.
+			_, err := books.Update(ctx, model.Book{ID: current.ID, Name: "Dune Messiah", Version: current.Version}, 2)
+			assert.NoError(t, err)
+		}
+		current.Subtitle = "Book One"
+
+		return current, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, attempts)
+	assert.Equal(t, "Dune Messiah", patched.Name)
+	assert.Equal(t, "Book One", patched.Subtitle)
+	assert.Equal(t, uint64(3), patched.Version)
+
+	_, err = books.Patch(ctx, book.ID, book.Version, 1, func(current model.Book) (model.Book, error) {
+		return current, nil
+	})
+	assert.ErrorIs(t, err, ErrVersionConflict)
+}
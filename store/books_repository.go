@@ -2,15 +2,19 @@ package store
 
 import (
 	"bookService/model"
-	"log"
+	"context"
+	"time"
 
-	ai "github.com/night-codes/mgo-ai"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
 )
 
 const (
 	collectionBooks = "books"
 )
 
+// notDeleted filters out trashed books.
+var notDeleted = obj{"$exists": false}
+
 type (
 	BooksRepository struct {
 		store          *MongoStore
@@ -25,54 +29,334 @@ func NewBooksRepository(store *MongoStore) *BooksRepository {
 	}
 }
 
-func (r *BooksRepository) GetAll() ([]model.Book, error) {
+func (r *BooksRepository) GetAll(ctx context.Context) ([]model.Book, error) {
 	results := []model.Book{}
-	err := r.store.conn.C(collectionBooks).Find(obj{}).All(&results)
+	cursor, err := r.store.conn.Collection(collectionBooks).Find(ctx, obj{"deleted_at": notDeleted})
+	if err == nil {
+		err = cursor.All(ctx, &results)
+	}
 	if err != nil {
-		log.Println("GetAll Find err: ", err)
+		logError(ctx, "GetAll Find", err)
 	}
 
-	return results, err
+	return results, mongoErr(err)
 }
 
-func (r *BooksRepository) Find(bookID uint64) (model.Book, error) {
+// Search returns one page of the books matching the query, using the text
+// and compound indexes of the books collection.
+func (r *BooksRepository) Search(ctx context.Context, query model.BookQuery) (model.BookPage, error) {
+	cursor, err := decodeBookCursor(query)
+	if err != nil {
+		return model.BookPage{}, err
+	}
+
+	filter := bookFilter(query)
+	page := model.BookPage{Items: []model.Book{}}
+	if query.WithTotal {
+		total, err := r.store.conn.Collection(collectionBooks).CountDocuments(ctx, filter)
+		if err != nil {
+			logError(ctx, "Search CountDocuments", err)
+
+			return model.BookPage{}, mongoErr(err)
+		}
+		n := int(total)
+		page.Total = &n
+	}
+
+	sort, after := bookSort(query, cursor)
+	if after != nil {
+		filter = obj{"$and": []obj{filter, after}}
+	}
+
+	found, err := r.store.conn.Collection(collectionBooks).Find(ctx, filter,
+		options.Find().SetSort(sort).SetLimit(int64(query.Limit+1)))
+	if err == nil {
+		err = found.All(ctx, &page.Items)
+	}
+	if err != nil {
+		logError(ctx, "Search Find", err)
+
+		return model.BookPage{}, mongoErr(err)
+	}
+
+	if len(page.Items) > query.Limit {
+		page.Items = page.Items[:query.Limit]
+		page.NextCursor = encodeBookCursor(ctx, query, page.Items[len(page.Items)-1])
+	}
+
+	return page, nil
+}
+
+func (r *BooksRepository) Find(ctx context.Context, bookID uint64) (model.Book, error) {
+	return r.findOne(ctx, obj{"_id": bookID, "deleted_at": notDeleted})
+}
+
+func (r *BooksRepository) FindDeleted(ctx context.Context, ID uint64) (model.Book, error) {
+	return r.findOne(ctx, obj{"_id": ID, "deleted_at": obj{"$exists": true}})
+}
+
+func (r *BooksRepository) findOne(ctx context.Context, filter obj) (model.Book, error) {
 	result := model.Book{}
-	err := r.store.conn.C(collectionBooks).FindId(bookID).One(&result)
+	err := r.store.conn.Collection(collectionBooks).FindOne(ctx, filter).Decode(&result)
 	if err != nil {
-		log.Println("Find FindId err: ", err)
+		logError(ctx, "findOne FindOne", err)
 
-		return model.Book{}, err
+		return model.Book{}, mongoErr(err)
 	}
 
 	return result, nil
 }
 
-func (r *BooksRepository) Insert(item model.Book, authorID uint64) error {
-	ai.Connect(r.store.conn.C("ai"))
-	item.ID = ai.Next(collectionBooks)
-	item.AuthorID = authorID
-	err := r.store.conn.C(collectionBooks).Insert(item)
+func (r *BooksRepository) Insert(ctx context.Context, item model.Book, ownerID uint64) (model.Book, error) {
+	item.ID = r.store.IDs.NewID()
+	item.OwnerID = ownerID
+	item.Version = 1
+	err := r.withRevision(ctx, newRevision(model.RevisionCreate, model.Book{}, item, ownerID), func() error {
+		_, err := r.store.conn.Collection(collectionBooks).InsertOne(ctx, item)
+		if err != nil {
+			logError(ctx, "Insert InsertOne", err)
+		}
+
+		return mongoErr(err)
+	})
+	if err != nil {
+		return model.Book{}, err
+	}
+
+	return item, nil
+}
+
+// Update replaces the stored book, so fields left empty are removed. Only
+// books outside the trash are updated and they stay there.
+func (r *BooksRepository) Update(ctx context.Context, item model.Book, actorID uint64) (model.Book, error) {
+	filter := obj{"_id": item.ID, "version": item.Version, "deleted_at": notDeleted}
+	before, err := r.findOne(ctx, filter)
+	if err == ErrNotFound {
+		return model.Book{}, r.conflict(ctx, item.ID)
+	}
 	if err != nil {
-		log.Println("Insert Insert err: ", err)
+		return model.Book{}, err
 	}
 
-	return err
+	item.DeletedAt = nil
+	item.DeletedBy = 0
+	item.Version++
+	err = r.withRevision(ctx, newRevision(model.RevisionUpdate, before, item, actorID), func() error {
+		result, err := r.store.conn.Collection(collectionBooks).ReplaceOne(ctx, filter, item)
+		if err != nil {
+			logError(ctx, "Update ReplaceOne", err)
+
+			return mongoErr(err)
+		}
+		if result.MatchedCount == 0 {
+			return r.conflict(ctx, item.ID)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return model.Book{}, err
+	}
+
+	return item, nil
+}
+
+func (r *BooksRepository) Patch(ctx context.Context, ID, version, actorID uint64, patch BookPatch) (model.Book, error) {
+	return patchBook(ctx, r, ID, version, actorID, patch)
+}
+
+// conflict tells a book that is gone from one changed by someone else after
+// a conditional write matched nothing.
+func (r *BooksRepository) conflict(ctx context.Context, ID uint64) error {
+	n, err := r.store.conn.Collection(collectionBooks).CountDocuments(ctx, obj{"_id": ID, "deleted_at": notDeleted})
+	if err != nil {
+		logError(ctx, "conflict CountDocuments", err)
+
+		return mongoErr(err)
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+
+	return ErrVersionConflict
 }
 
-func (r *BooksRepository) Update(item model.Book) error {
-	err := r.store.conn.C(collectionBooks).UpdateId(item.ID, obj{"$set": item})
+// CountByContributor counts the books the author contributed to.
+func (r *BooksRepository) CountByContributor(ctx context.Context, authorID uint64) (int, error) {
+	n, err := r.store.conn.Collection(collectionBooks).CountDocuments(ctx, obj{"contributors.author_id": authorID})
 	if err != nil {
-		log.Println("Update UpdateId err: ", err)
+		logError(ctx, "CountByContributor CountDocuments", err)
 	}
 
-	return err
+	return int(n), mongoErr(err)
 }
 
-func (r *BooksRepository) Delete(ID uint64) error {
-	err := r.store.conn.C(collectionBooks).Remove(obj{"_id": ID})
+func (r *BooksRepository) Delete(ctx context.Context, ID, version, deletedBy uint64) error {
+	filter := obj{"_id": ID, "version": version, "deleted_at": notDeleted}
+	before, err := r.findOne(ctx, filter)
+	if err == ErrNotFound {
+		return r.conflict(ctx, ID)
+	}
 	if err != nil {
-		log.Println("Delete Remove err: ", err)
+		return err
+	}
+
+	// Mongo keeps milliseconds, the snapshot has to match what is stored.
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	after := before
+	after.DeletedAt = &now
+	after.DeletedBy = deletedBy
+	after.Version++
+
+	return r.withRevision(ctx, newRevision(model.RevisionDelete, before, after, deletedBy), func() error {
+		result, err := r.store.conn.Collection(collectionBooks).UpdateOne(ctx, filter, obj{
+			"$set": obj{"deleted_at": now, "deleted_by": deletedBy},
+			"$inc": obj{"version": 1},
+		})
+		if err != nil {
+			logError(ctx, "Delete UpdateOne", err)
+
+			return mongoErr(err)
+		}
+		if result.MatchedCount == 0 {
+			return r.conflict(ctx, ID)
+		}
+
+		return nil
+	})
+}
+
+func (r *BooksRepository) Trash(ctx context.Context, ownerID uint64, offset, limit int) ([]model.Book, int, error) {
+	filter := obj{"deleted_at": obj{"$exists": true}}
+	if ownerID != 0 {
+		filter["owner_id"] = ownerID
+	}
+
+	total, err := r.store.conn.Collection(collectionBooks).CountDocuments(ctx, filter)
+	if err != nil {
+		logError(ctx, "Trash CountDocuments", err)
+
+		return nil, 0, mongoErr(err)
+	}
+
+	results := []model.Book{}
+	cursor, err := r.store.conn.Collection(collectionBooks).Find(ctx, filter, options.Find().
+		SetSort(keys("-deleted_at", "_id")).
+		SetSkip(int64(offset)).
+		SetLimit(int64(limit)))
+	if err == nil {
+		err = cursor.All(ctx, &results)
+	}
+	if err != nil {
+		logError(ctx, "Trash Find", err)
+
+		return nil, 0, mongoErr(err)
+	}
+
+	return results, int(total), nil
+}
+
+func (r *BooksRepository) Restore(ctx context.Context, ID, actorID uint64) (model.Book, error) {
+	before, err := r.FindDeleted(ctx, ID)
+	if err != nil {
+		return model.Book{}, err
+	}
+
+	after := before
+	after.DeletedAt = nil
+	after.DeletedBy = 0
+	after.Version++
+	err = r.withRevision(ctx, newRevision(model.RevisionRestore, before, after, actorID), func() error {
+		result, err := r.store.conn.Collection(collectionBooks).UpdateOne(ctx,
+			obj{"_id": ID, "version": before.Version, "deleted_at": obj{"$exists": true}},
+			obj{
+				"$unset": obj{"deleted_at": "", "deleted_by": ""},
+				"$inc":   obj{"version": 1},
+			},
+		)
+		if err != nil {
+			logError(ctx, "Restore UpdateOne", err)
+
+			return mongoErr(err)
+		}
+
+		return matched(result.MatchedCount)
+	})
+	// The book was restored or purged meanwhile, it is no longer in the
+	// trash.
+	if err == ErrVersionConflict {
+		return model.Book{}, ErrNotFound
+	}
+	if err != nil {
+		return model.Book{}, err
+	}
+
+	return after, nil
+}
+
+// Purge leaves the history of the purged books and ends it with a purge
+// revision. The revision is written first and the book is only removed at
+// the version the revision follows, a book changed meanwhile stays.
+func (r *BooksRepository) Purge(ctx context.Context, before time.Time) (int, error) {
+	filter := obj{"deleted_at": obj{"$lt": before}}
+	purged := []model.Book{}
+	cursor, err := r.store.conn.Collection(collectionBooks).Find(ctx, filter)
+	if err == nil {
+		err = cursor.All(ctx, &purged)
+	}
+	if err != nil {
+		logError(ctx, "Purge Find", err)
+
+		return 0, mongoErr(err)
+	}
+
+	n := 0
+	for _, book := range purged {
+		err := r.withRevision(ctx, purgeRevision(book), func() error {
+			result, err := r.store.conn.Collection(collectionBooks).DeleteOne(ctx,
+				obj{"_id": book.ID, "version": book.Version, "deleted_at": obj{"$lt": before}})
+			if err != nil {
+				logError(ctx, "Purge DeleteOne", err)
+
+				return mongoErr(err)
+			}
+			if result.DeletedCount == 0 {
+				return ErrVersionConflict
+			}
+
+			return nil
+		})
+		if err == ErrVersionConflict {
+			continue
+		}
+		if err != nil {
+			return n, err
+		}
+		n++
+	}
+
+	return n, nil
+}
+
+// withRevision stores the revision of a change before write makes the
+// change, so that no change is left out of the history. The change is not
+// made when its revision cannot be stored, and the revision is discarded
+// when write fails. Revisions are unique per book and version, a revision
+// already stored means another change took the version first.
+func (r *BooksRepository) withRevision(ctx context.Context, revision model.BookRevision, write func() error) error {
+	revisionID, err := r.store.RevisionsRepository.insert(ctx, revision)
+	if err == ErrDuplicate {
+		return ErrVersionConflict
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := write(); err != nil {
+		r.store.RevisionsRepository.discard(ctx, revisionID)
+
+		return err
 	}
 
-	return err
+	return nil
 }
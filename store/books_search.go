@@ -0,0 +1,113 @@
+package store
+
+import (
+	"bookService/logging"
+	"bookService/model"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// bookCursor is the position after the last book of a page. It carries the
+// sort it was made for so it cannot be replayed against another ordering.
+type bookCursor struct {
+	Sort string `json:"s"`
+	Desc bool   `json:"d,omitempty"`
+	Name string `json:"n,omitempty"`
+	ID   uint64 `json:"i"`
+}
+
+func encodeBookCursor(ctx context.Context, query model.BookQuery, last model.Book) string {
+	cursor := bookCursor{Sort: query.Sort, Desc: query.Desc, ID: last.ID}
+	if query.Sort == model.BookSortName {
+		cursor.Name = last.Name
+	}
+
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		logging.FromContext(ctx).Error("encodeBookCursor Marshal", "err", err)
+
+		return ""
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeBookCursor(query model.BookQuery) (*bookCursor, error) {
+	if query.Cursor == "" {
+		return nil, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(query.Cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	cursor := &bookCursor{}
+	if err := json.Unmarshal(data, cursor); err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	if cursor.Sort != query.Sort || cursor.Desc != query.Desc {
+		return nil, ErrInvalidCursor
+	}
+
+	return cursor, nil
+}
+
+func bookFilter(query model.BookQuery) obj {
+	filter := obj{"deleted_at": notDeleted}
+	if query.Text != "" {
+		filter["$text"] = obj{"$search": query.Text}
+	}
+	if query.AuthorID != 0 {
+		filter["contributors.author_id"] = query.AuthorID
+	}
+	if query.OwnerID != 0 {
+		filter["owner_id"] = query.OwnerID
+	}
+	if query.ISBN != "" {
+		filter["isbn"] = query.ISBN
+	}
+	if query.Language != "" {
+		filter["language"] = query.Language
+	}
+	if query.Genre != "" {
+		filter["genres"] = query.Genre
+	}
+
+	return filter
+}
+
+// bookSort returns the sort and, for a cursor, the keyset condition
+// selecting the books after it. _id breaks ties so the order is total.
+func bookSort(query model.BookQuery, cursor *bookCursor) (bson.D, obj) {
+	op, prefix := "$gt", ""
+	if query.Desc {
+		op, prefix = "$lt", "-"
+	}
+
+	if query.Sort == model.BookSortName {
+		fields := keys(prefix+"name", prefix+"_id")
+		if cursor == nil {
+			return fields, nil
+		}
+
+		return fields, obj{"$or": []obj{
+			{"name": obj{op: cursor.Name}},
+			{"name": cursor.Name, "_id": obj{op: cursor.ID}},
+		}}
+	}
+
+	fields := keys(prefix + "_id")
+	if cursor == nil {
+		return fields, nil
+	}
+
+	return fields, obj{"_id": obj{op: cursor.ID}}
+}
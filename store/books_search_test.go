@@ -0,0 +1,39 @@
+package store
+
+import (
+	"bookService/model"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestBookCursorRoundTrip(t *testing.T) {
+	query := model.BookQuery{Sort: model.BookSortName, Desc: true, Limit: 10}
+	query.Cursor = encodeBookCursor(context.Background(), query, model.Book{ID: 42, Name: "Dune"})
+
+	cursor, err := decodeBookCursor(query)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(42), cursor.ID)
+	assert.Equal(t, "Dune", cursor.Name)
+
+	sort, after := bookSort(query, cursor)
+	assert.Equal(t, bson.D{{Key: "name", Value: -1}, {Key: "_id", Value: -1}}, sort)
+	assert.Equal(t, obj{"$or": []obj{
+		{"name": obj{"$lt": "Dune"}},
+		{"name": "Dune", "_id": obj{"$lt": uint64(42)}},
+	}}, after)
+}
+
+func TestBookCursorRejectsOtherSort(t *testing.T) {
+	query := model.BookQuery{Sort: model.BookSortName}
+	query.Cursor = encodeBookCursor(context.Background(), query, model.Book{ID: 42, Name: "Dune"})
+	query.Sort = model.BookSortID
+
+	_, err := decodeBookCursor(query)
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+
+	_, err = decodeBookCursor(model.BookQuery{Cursor: "not a cursor"})
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
@@ -0,0 +1,57 @@
+package store
+
+import (
+	"bookService/logging"
+	"bookService/model"
+	"context"
+)
+
+// SeedFixtures fills an empty catalog with a few authors and books for
+// development. It does nothing if any book exists and never creates users.
+func SeedFixtures(ctx context.Context, s Store) error {
+	page, err := s.Books().Search(ctx, model.BookQuery{Limit: 1})
+	if err != nil {
+		return err
+	}
+	if len(page.Items) > 0 {
+		logging.FromContext(ctx).Error("SeedFixtures catalog not empty, skipping")
+
+		return nil
+	}
+
+	herbert, err := s.Authors().Insert(ctx, model.Author{Name: "Frank Herbert", BirthDate: "1920-10-08", DeathDate: "1986-02-11"})
+	if err != nil {
+		return err
+	}
+	austen, err := s.Authors().Insert(ctx, model.Author{Name: "Jane Austen", BirthDate: "1775-12-16", DeathDate: "1817-07-18"})
+	if err != nil {
+		return err
+	}
+
+	books := []model.Book{
+		{
+			Name: "Dune", ISBN: "9780441013593", Language: "en", PublicationDate: "1965",
+			Genres:       []string{"science fiction"},
+			Contributors: []model.Contributor{{AuthorID: herbert.ID, Role: model.ContributorAuthor}},
+		},
+		{
+			Name: "Dune Messiah", ISBN: "9780593098233", Language: "en", PublicationDate: "1969",
+			Genres:       []string{"science fiction"},
+			Contributors: []model.Contributor{{AuthorID: herbert.ID, Role: model.ContributorAuthor}},
+		},
+		{
+			Name: "Emma", ISBN: "9780141439587", Language: "en", PublicationDate: "1815",
+			Genres:       []string{"romance"},
+			Contributors: []model.Contributor{{AuthorID: austen.ID, Role: model.ContributorAuthor}},
+		},
+	}
+	for _, book := range books {
+		if _, err := s.Books().Insert(ctx, book, 0); err != nil {
+			return err
+		}
+	}
+
+	logging.FromContext(ctx).Info("SeedFixtures inserted", "authors", 2, "books", len(books))
+
+	return nil
+}
@@ -0,0 +1,27 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeedFixturesOnce(t *testing.T) {
+	ctx := context.Background()
+	memory := NewMemoryStore()
+
+	assert.NoError(t, SeedFixtures(ctx, memory))
+	assert.NoError(t, SeedFixtures(ctx, memory))
+
+	books, err := memory.Books().GetAll(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, books, 3)
+	for _, book := range books {
+		assert.NoError(t, book.Validate(), book.Name)
+	}
+
+	users, err := memory.Users().GetAll(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, users)
+}
@@ -0,0 +1,92 @@
+package store
+
+import (
+	"bookService/config"
+	"hash/fnv"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	snowflakeNodeBits     = 10
+	snowflakeSequenceBits = 12
+	snowflakeMaxNode      = 1<<snowflakeNodeBits - 1
+	snowflakeMaxSequence  = 1<<snowflakeSequenceBits - 1
+)
+
+// snowflakeEpoch is the zero of the timestamp part. Ids issued by the old
+// auto increment counters are far below the first snowflake id, so both
+// kinds sort by creation time.
+var snowflakeEpoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// IDGenerator hands out the ids of new records.
+type IDGenerator interface {
+	NewID() uint64
+}
+
+// Snowflake generates 63 bit ids from a millisecond timestamp, a node
+// number and a sequence, so replicas with distinct nodes never collide and
+// need no round trip to the database.
+type Snowflake struct {
+	mu       sync.Mutex
+	node     uint64
+	lastMS   int64
+	sequence uint64
+}
+
+func NewSnowflake(node int) *Snowflake {
+	return &Snowflake{node: uint64(node) & snowflakeMaxNode}
+}
+
+// NodeFromHostname derives a node number from the host name, which is
+// unique per replica in most deployments. Set the node explicitly where it
+// is not.
+func NodeFromHostname() int {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return 0
+	}
+
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(hostname))
+
+	return int(hash.Sum32() & snowflakeMaxNode)
+}
+
+// newIDGenerator builds the generator configured by STORE_NODE_ID.
+func newIDGenerator(conf config.StoreConfig) IDGenerator {
+	node := conf.NodeID
+	if node < 0 {
+		node = NodeFromHostname()
+	}
+
+	return NewSnowflake(node)
+}
+
+func (s *Snowflake) NewID() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Since(snowflakeEpoch).Milliseconds()
+	// A clock moving backwards keeps counting from the last timestamp
+	// rather than reissuing ids.
+	if now < s.lastMS {
+		now = s.lastMS
+	}
+
+	if now == s.lastMS {
+		s.sequence = (s.sequence + 1) & snowflakeMaxSequence
+		if s.sequence == 0 {
+			// The sequence of this millisecond is used up, borrow the next.
+			now++
+		}
+	} else {
+		s.sequence = 0
+	}
+	s.lastMS = now
+
+	return uint64(now)<<(snowflakeNodeBits+snowflakeSequenceBits) |
+		s.node<<snowflakeSequenceBits |
+		s.sequence
+}
@@ -0,0 +1,41 @@
+package store
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnowflakeUnique(t *testing.T) {
+	ids := NewSnowflake(7)
+
+	var mu sync.Mutex
+	seen := map[uint64]bool{}
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10000; j++ {
+				ID := ids.NewID()
+				mu.Lock()
+				seen[ID] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Len(t, seen, 40000)
+}
+
+func TestSnowflakeLayout(t *testing.T) {
+	first := NewSnowflake(7).NewID()
+	second := NewSnowflake(8).NewID()
+
+	assert.Equal(t, uint64(7), first>>snowflakeSequenceBits&snowflakeMaxNode)
+	assert.Equal(t, uint64(8), second>>snowflakeSequenceBits&snowflakeMaxNode)
+	assert.NotEqual(t, first, second)
+	assert.Less(t, first, uint64(1)<<63)
+}
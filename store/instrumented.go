@@ -0,0 +1,306 @@
+package store
+
+import (
+	"bookService/metrics"
+	"bookService/model"
+	"bookService/tracing"
+	"context"
+	"errors"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	repositoryBooks = "books"
+	repositoryUsers = "users"
+)
+
+// instrumentedStore records the latency and errors of the book and user
+// operations of a backend in metrics and traces them.
+type instrumentedStore struct {
+	Store
+}
+
+// Instrument wraps s so that its book and user operations are measured and
+// traced.
+func Instrument(s Store) Store {
+	return instrumentedStore{s}
+}
+
+func (s instrumentedStore) Books() BookStore {
+	return instrumentedBooks{s.Store.Books()}
+}
+
+func (s instrumentedStore) Users() UserStore {
+	return instrumentedUsers{s.Store.Users()}
+}
+
+// call is a store operation in progress.
+type call struct {
+	repository string
+	operation  string
+	start      time.Time
+	span       trace.Span
+}
+
+// begin starts timing an operation and a span for it, which the returned
+// context carries down to the backend.
+func begin(ctx context.Context, repository, operation string) (context.Context, *call) {
+	ctx, span := tracing.Start(ctx, "store."+repository+"."+operation,
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithAttributes(attribute.String("store.repository", repository)),
+	)
+
+	return ctx, &call{
+		repository: repository,
+		operation:  operation,
+		start:      time.Now(),
+		span:       span,
+	}
+}
+
+// end records the outcome. Missing records and conflicts are answers rather
+// than failures, they do not mark the span as failed.
+func (c *call) end(err error) {
+	metrics.StoreDuration.WithLabelValues(c.repository, c.operation).Observe(time.Since(c.start).Seconds())
+	if err == nil {
+		c.span.End()
+
+		return
+	}
+
+	kind := errorKind(err)
+	metrics.StoreErrors.WithLabelValues(c.repository, c.operation, kind).Inc()
+	c.span.SetAttributes(attribute.String("store.error", kind))
+	if kind == "other" || kind == "timeout" {
+		tracing.End(c.span, err)
+	} else {
+		c.span.End()
+	}
+}
+
+// errorKind keeps the error label to a few values.
+func errorKind(err error) string {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return "not_found"
+	case errors.Is(err, ErrDuplicate):
+		return "duplicate"
+	case errors.Is(err, ErrVersionConflict):
+		return "conflict"
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	}
+
+	return "other"
+}
+
+type instrumentedBooks struct {
+	books BookStore
+}
+
+func (b instrumentedBooks) GetAll(ctx context.Context) ([]model.Book, error) {
+	ctx, call := begin(ctx, repositoryBooks, "GetAll")
+	results, err := b.books.GetAll(ctx)
+	call.end(err)
+
+	return results, err
+}
+
+func (b instrumentedBooks) Search(ctx context.Context, query model.BookQuery) (model.BookPage, error) {
+	ctx, call := begin(ctx, repositoryBooks, "Search")
+	page, err := b.books.Search(ctx, query)
+	call.end(err)
+
+	return page, err
+}
+
+func (b instrumentedBooks) Find(ctx context.Context, bookID uint64) (model.Book, error) {
+	ctx, call := begin(ctx, repositoryBooks, "Find")
+	book, err := b.books.Find(ctx, bookID)
+	call.end(err)
+
+	return book, err
+}
+
+func (b instrumentedBooks) Insert(ctx context.Context, item model.Book, ownerID uint64) (model.Book, error) {
+	ctx, call := begin(ctx, repositoryBooks, "Insert")
+	book, err := b.books.Insert(ctx, item, ownerID)
+	call.end(err)
+
+	return book, err
+}
+
+func (b instrumentedBooks) Update(ctx context.Context, item model.Book, actorID uint64) (model.Book, error) {
+	ctx, call := begin(ctx, repositoryBooks, "Update")
+	book, err := b.books.Update(ctx, item, actorID)
+	call.end(err)
+
+	return book, err
+}
+
+func (b instrumentedBooks) Patch(ctx context.Context, ID, version, actorID uint64, patch BookPatch) (model.Book, error) {
+	ctx, call := begin(ctx, repositoryBooks, "Patch")
+	book, err := b.books.Patch(ctx, ID, version, actorID, patch)
+	call.end(err)
+
+	return book, err
+}
+
+func (b instrumentedBooks) Delete(ctx context.Context, ID, version, deletedBy uint64) error {
+	ctx, call := begin(ctx, repositoryBooks, "Delete")
+	err := b.books.Delete(ctx, ID, version, deletedBy)
+	call.end(err)
+
+	return err
+}
+
+func (b instrumentedBooks) CountByContributor(ctx context.Context, authorID uint64) (int, error) {
+	ctx, call := begin(ctx, repositoryBooks, "CountByContributor")
+	n, err := b.books.CountByContributor(ctx, authorID)
+	call.end(err)
+
+	return n, err
+}
+
+func (b instrumentedBooks) Trash(ctx context.Context, ownerID uint64, offset, limit int) ([]model.Book, int, error) {
+	ctx, call := begin(ctx, repositoryBooks, "Trash")
+	results, total, err := b.books.Trash(ctx, ownerID, offset, limit)
+	call.end(err)
+
+	return results, total, err
+}
+
+func (b instrumentedBooks) FindDeleted(ctx context.Context, ID uint64) (model.Book, error) {
+	ctx, call := begin(ctx, repositoryBooks, "FindDeleted")
+	book, err := b.books.FindDeleted(ctx, ID)
+	call.end(err)
+
+	return book, err
+}
+
+func (b instrumentedBooks) Restore(ctx context.Context, ID, actorID uint64) (model.Book, error) {
+	ctx, call := begin(ctx, repositoryBooks, "Restore")
+	book, err := b.books.Restore(ctx, ID, actorID)
+	call.end(err)
+
+	return book, err
+}
+
+func (b instrumentedBooks) Purge(ctx context.Context, before time.Time) (int, error) {
+	ctx, call := begin(ctx, repositoryBooks, "Purge")
+	n, err := b.books.Purge(ctx, before)
+	call.end(err)
+
+	return n, err
+}
+
+type instrumentedUsers struct {
+	users UserStore
+}
+
+func (u instrumentedUsers) GetAll(ctx context.Context) ([]model.User, error) {
+	ctx, call := begin(ctx, repositoryUsers, "GetAll")
+	results, err := u.users.GetAll(ctx)
+	call.end(err)
+
+	return results, err
+}
+
+func (u instrumentedUsers) Search(ctx context.Context, login string, offset, limit int) ([]model.User, int, error) {
+	ctx, call := begin(ctx, repositoryUsers, "Search")
+	results, total, err := u.users.Search(ctx, login, offset, limit)
+	call.end(err)
+
+	return results, total, err
+}
+
+func (u instrumentedUsers) Find(ctx context.Context, userID uint64) (model.User, error) {
+	ctx, call := begin(ctx, repositoryUsers, "Find")
+	user, err := u.users.Find(ctx, userID)
+	call.end(err)
+
+	return user, err
+}
+
+func (u instrumentedUsers) GetByLogin(ctx context.Context, login string) (*model.User, error) {
+	ctx, call := begin(ctx, repositoryUsers, "GetByLogin")
+	user, err := u.users.GetByLogin(ctx, login)
+	call.end(err)
+
+	return user, err
+}
+
+func (u instrumentedUsers) Insert(ctx context.Context, item model.User) (model.User, error) {
+	ctx, call := begin(ctx, repositoryUsers, "Insert")
+	user, err := u.users.Insert(ctx, item)
+	call.end(err)
+
+	return user, err
+}
+
+func (u instrumentedUsers) Update(ctx context.Context, item model.User) error {
+	ctx, call := begin(ctx, repositoryUsers, "Update")
+	err := u.users.Update(ctx, item)
+	call.end(err)
+
+	return err
+}
+
+func (u instrumentedUsers) Delete(ctx context.Context, ID uint64) error {
+	ctx, call := begin(ctx, repositoryUsers, "Delete")
+	err := u.users.Delete(ctx, ID)
+	call.end(err)
+
+	return err
+}
+
+func (u instrumentedUsers) SaveRecoveryToken(ctx context.Context, userID uint64, recoveryToken string) error {
+	ctx, call := begin(ctx, repositoryUsers, "SaveRecoveryToken")
+	err := u.users.SaveRecoveryToken(ctx, userID, recoveryToken)
+	call.end(err)
+
+	return err
+}
+
+func (u instrumentedUsers) VerifyRecoveryToken(ctx context.Context, recoveryToken string) (uint64, error) {
+	ctx, call := begin(ctx, repositoryUsers, "VerifyRecoveryToken")
+	userID, err := u.users.VerifyRecoveryToken(ctx, recoveryToken)
+	call.end(err)
+
+	return userID, err
+}
+
+func (u instrumentedUsers) SetPassword(ctx context.Context, userID uint64, hashedPassword string) error {
+	ctx, call := begin(ctx, repositoryUsers, "SetPassword")
+	err := u.users.SetPassword(ctx, userID, hashedPassword)
+	call.end(err)
+
+	return err
+}
+
+func (u instrumentedUsers) SetRole(ctx context.Context, userID uint64, role model.Role) error {
+	ctx, call := begin(ctx, repositoryUsers, "SetRole")
+	err := u.users.SetRole(ctx, userID, role)
+	call.end(err)
+
+	return err
+}
+
+func (u instrumentedUsers) SetLocked(ctx context.Context, userID uint64, locked bool) error {
+	ctx, call := begin(ctx, repositoryUsers, "SetLocked")
+	err := u.users.SetLocked(ctx, userID, locked)
+	call.end(err)
+
+	return err
+}
+
+func (u instrumentedUsers) RequirePasswordReset(ctx context.Context, userID uint64, recoveryToken string) error {
+	ctx, call := begin(ctx, repositoryUsers, "RequirePasswordReset")
+	err := u.users.RequirePasswordReset(ctx, userID, recoveryToken)
+	call.end(err)
+
+	return err
+}
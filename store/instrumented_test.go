@@ -0,0 +1,28 @@
+package store
+
+import (
+	"bookService/metrics"
+	"bookService/model"
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInstrumentCountsErrors(t *testing.T) {
+	s := Instrument(NewMemoryStore())
+	ctx := context.Background()
+	notFound := metrics.StoreErrors.WithLabelValues(repositoryBooks, "Find", "not_found")
+	before := testutil.ToFloat64(notFound)
+
+	book, err := s.Books().Insert(ctx, model.Book{Name: "Dune"}, 1)
+	assert.NoError(t, err)
+	_, err = s.Books().Find(ctx, book.ID)
+	assert.NoError(t, err)
+	_, err = s.Books().Find(ctx, book.ID+1)
+	assert.Equal(t, ErrNotFound, err)
+
+	assert.Equal(t, before+1, testutil.ToFloat64(notFound))
+	assert.Positive(t, testutil.CollectAndCount(metrics.StoreDuration))
+}
@@ -0,0 +1,19 @@
+package store
+
+import (
+	"bookService/logging"
+	"context"
+	"log/slog"
+)
+
+// logError logs a failed database operation. Missing documents and
+// duplicates are answered to the client and only logged at debug level.
+func logError(ctx context.Context, op string, err error) {
+	level := slog.LevelError
+	switch mongoErr(err) {
+	case ErrNotFound, ErrDuplicate, ErrVersionConflict:
+		level = slog.LevelDebug
+	}
+
+	logging.FromContext(ctx).Log(ctx, level, op, "err", err)
+}
@@ -0,0 +1,579 @@
+package store
+
+import (
+	"bookService/health"
+	"bookService/model"
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var _ Store = (*MemoryStore)(nil)
+
+// MemoryStore keeps everything in process memory. It is meant for tests and
+// for running the service without MongoDB, nothing survives a restart.
+type MemoryStore struct {
+	mu sync.RWMutex
+
+	books    map[uint64]model.Book
+	users    map[uint64]model.User
+	authors  map[uint64]model.Author
+	tokens   map[string]model.RefreshToken
+	sessions map[string]model.Session
+
+	// revisionsMu is taken after mu, so books can record revisions while
+	// holding mu.
+	revisionsMu sync.RWMutex
+	revisions   map[uint64][]model.BookRevision
+
+	auditMu sync.RWMutex
+	audit   []model.AuditEvent
+
+	IDs IDGenerator
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		books:     map[uint64]model.Book{},
+		users:     map[uint64]model.User{},
+		authors:   map[uint64]model.Author{},
+		tokens:    map[string]model.RefreshToken{},
+		sessions:  map[string]model.Session{},
+		revisions: map[uint64][]model.BookRevision{},
+		IDs:       NewSnowflake(0),
+	}
+}
+
+func (s *MemoryStore) Books() BookStore {
+	return memoryBooks{s}
+}
+
+func (s *MemoryStore) Revisions() RevisionStore {
+	return memoryRevisions{s}
+}
+
+// Checks is empty, the memory backend is always ready.
+func (s *MemoryStore) Checks() []health.Check {
+	return nil
+}
+
+// Close does nothing, the memory backend holds no connections.
+func (s *MemoryStore) Close(ctx context.Context) error {
+	return nil
+}
+
+func (s *MemoryStore) Audit() AuditStore {
+	return memoryAudit{s}
+}
+
+func (s *MemoryStore) Users() UserStore {
+	return memoryUsers{s}
+}
+
+func (s *MemoryStore) Authors() AuthorStore {
+	return memoryAuthors{s}
+}
+
+func (s *MemoryStore) Tokens() TokenStore {
+	return memoryTokens{s}
+}
+
+func (s *MemoryStore) Sessions() SessionStore {
+	return memorySessions{s}
+}
+
+type memoryBooks struct {
+	s *MemoryStore
+}
+
+func (r memoryBooks) GetAll(ctx context.Context) ([]model.Book, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	results := make([]model.Book, 0, len(r.s.books))
+	for _, book := range r.s.books {
+		if book.DeletedAt == nil {
+			results = append(results, book)
+		}
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].ID < results[j].ID })
+
+	return results, nil
+}
+
+// Search mirrors BooksRepository.Search, including its cursors, so clients
+// cannot tell the backends apart.
+func (r memoryBooks) Search(ctx context.Context, query model.BookQuery) (model.BookPage, error) {
+	cursor, err := decodeBookCursor(query)
+	if err != nil {
+		return model.BookPage{}, err
+	}
+
+	r.s.mu.RLock()
+	matches := []model.Book{}
+	for _, book := range r.s.books {
+		if book.DeletedAt == nil && bookMatches(query, book) {
+			matches = append(matches, book)
+		}
+	}
+	r.s.mu.RUnlock()
+
+	page := model.BookPage{Items: []model.Book{}}
+	if query.WithTotal {
+		total := len(matches)
+		page.Total = &total
+	}
+
+	less := func(a, b model.Book) bool {
+		if query.Sort == model.BookSortName && a.Name != b.Name {
+			return a.Name < b.Name
+		}
+
+		return a.ID < b.ID
+	}
+	if query.Desc {
+		asc := less
+		less = func(a, b model.Book) bool { return asc(b, a) }
+	}
+	sort.Slice(matches, func(i, j int) bool { return less(matches[i], matches[j]) })
+
+	for _, book := range matches {
+		if cursor != nil && !less(model.Book{ID: cursor.ID, Name: cursor.Name}, book) {
+			continue
+		}
+		if len(page.Items) == query.Limit {
+			page.NextCursor = encodeBookCursor(ctx, query, page.Items[len(page.Items)-1])
+
+			break
+		}
+		page.Items = append(page.Items, book)
+	}
+
+	return page, nil
+}
+
+func (r memoryBooks) Find(ctx context.Context, bookID uint64) (model.Book, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	book, ok := r.s.books[bookID]
+	if !ok || book.DeletedAt != nil {
+		return model.Book{}, ErrNotFound
+	}
+
+	return book, nil
+}
+
+func (r memoryBooks) FindDeleted(ctx context.Context, ID uint64) (model.Book, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	book, ok := r.s.books[ID]
+	if !ok || book.DeletedAt == nil {
+		return model.Book{}, ErrNotFound
+	}
+
+	return book, nil
+}
+
+func (r memoryBooks) Insert(ctx context.Context, item model.Book, ownerID uint64) (model.Book, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if r.isbnTaken(item) {
+		return model.Book{}, ErrDuplicate
+	}
+
+	item.ID = r.s.IDs.NewID()
+	item.OwnerID = ownerID
+	item.Version = 1
+	if err := recordRevision(ctx, memoryRevisions{r.s}, model.RevisionCreate, model.Book{}, item, ownerID); err != nil {
+		return model.Book{}, err
+	}
+	r.s.books[item.ID] = item
+
+	return item, nil
+}
+
+func (r memoryBooks) Update(ctx context.Context, item model.Book, actorID uint64) (model.Book, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	stored, ok := r.s.books[item.ID]
+	if !ok || stored.DeletedAt != nil {
+		return model.Book{}, ErrNotFound
+	}
+	if stored.Version != item.Version {
+		return model.Book{}, ErrVersionConflict
+	}
+	if r.isbnTaken(item) {
+		return model.Book{}, ErrDuplicate
+	}
+	item.DeletedAt = nil
+	item.DeletedBy = 0
+	item.Version++
+	if err := recordRevision(ctx, memoryRevisions{r.s}, model.RevisionUpdate, stored, item, actorID); err != nil {
+		return model.Book{}, err
+	}
+	r.s.books[item.ID] = item
+
+	return item, nil
+}
+
+func (r memoryBooks) Patch(ctx context.Context, ID, version, actorID uint64, patch BookPatch) (model.Book, error) {
+	return patchBook(ctx, r, ID, version, actorID, patch)
+}
+
+func (r memoryBooks) Delete(ctx context.Context, ID, version, deletedBy uint64) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	stored, ok := r.s.books[ID]
+	if !ok || stored.DeletedAt != nil {
+		return ErrNotFound
+	}
+	if stored.Version != version {
+		return ErrVersionConflict
+	}
+	now := time.Now()
+	deleted := stored
+	deleted.DeletedAt = &now
+	deleted.DeletedBy = deletedBy
+	deleted.Version++
+	if err := recordRevision(ctx, memoryRevisions{r.s}, model.RevisionDelete, stored, deleted, deletedBy); err != nil {
+		return err
+	}
+	r.s.books[ID] = deleted
+
+	return nil
+}
+
+func (r memoryBooks) Trash(ctx context.Context, ownerID uint64, offset, limit int) ([]model.Book, int, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	results := []model.Book{}
+	for _, book := range r.s.books {
+		if book.DeletedAt != nil && (ownerID == 0 || book.OwnerID == ownerID) {
+			results = append(results, book)
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if !results[i].DeletedAt.Equal(*results[j].DeletedAt) {
+			return results[i].DeletedAt.After(*results[j].DeletedAt)
+		}
+
+		return results[i].ID < results[j].ID
+	})
+
+	return paginate(results, offset, limit), len(results), nil
+}
+
+func (r memoryBooks) Restore(ctx context.Context, ID, actorID uint64) (model.Book, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	stored, ok := r.s.books[ID]
+	if !ok || stored.DeletedAt == nil {
+		return model.Book{}, ErrNotFound
+	}
+	if r.isbnTaken(stored) {
+		return model.Book{}, ErrDuplicate
+	}
+	book := stored
+	book.DeletedAt = nil
+	book.DeletedBy = 0
+	book.Version++
+	if err := recordRevision(ctx, memoryRevisions{r.s}, model.RevisionRestore, stored, book, actorID); err != nil {
+		return model.Book{}, err
+	}
+	r.s.books[ID] = book
+
+	return book, nil
+}
+
+func (r memoryBooks) Purge(ctx context.Context, before time.Time) (int, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	n := 0
+	for ID, book := range r.s.books {
+		if book.DeletedAt != nil && book.DeletedAt.Before(before) {
+			if err := (memoryRevisions{r.s}).Insert(ctx, purgeRevision(book)); err != nil {
+				return n, err
+			}
+			delete(r.s.books, ID)
+			n++
+		}
+	}
+
+	return n, nil
+}
+
+func (r memoryBooks) CountByContributor(ctx context.Context, authorID uint64) (int, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	n := 0
+	for _, book := range r.s.books {
+		if book.HasContributor(authorID) {
+			n++
+		}
+	}
+
+	return n, nil
+}
+
+// isbnTaken enforces the unique isbn index of the books collection, which
+// only covers books outside the trash.
+func (r memoryBooks) isbnTaken(item model.Book) bool {
+	if item.ISBN == "" {
+		return false
+	}
+	for _, book := range r.s.books {
+		if book.ID != item.ID && book.ISBN == item.ISBN && book.DeletedAt == nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+func bookMatches(query model.BookQuery, book model.Book) bool {
+	switch {
+	case query.Text != "" && !textMatches(query.Text, book.Name, book.Subtitle, book.Description, strings.Join(book.Subjects, " ")):
+		return false
+	case query.AuthorID != 0 && !book.HasContributor(query.AuthorID):
+		return false
+	case query.OwnerID != 0 && book.OwnerID != query.OwnerID:
+		return false
+	case query.ISBN != "" && book.ISBN != query.ISBN:
+		return false
+	case query.Language != "" && book.Language != query.Language:
+		return false
+	case query.Genre != "" && !containsString(book.Genres, query.Genre):
+		return false
+	}
+
+	return true
+}
+
+// textMatches approximates a MongoDB $text search: a document matches if
+// any of the search terms appears as a word in one of the fields.
+func textMatches(search string, fields ...string) bool {
+	words := map[string]bool{}
+	for _, field := range fields {
+		for _, word := range strings.FieldsFunc(strings.ToLower(field), isWordSeparator) {
+			words[word] = true
+		}
+	}
+
+	for _, term := range strings.FieldsFunc(strings.ToLower(search), isWordSeparator) {
+		if words[term] {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isWordSeparator(r rune) bool {
+	return !('a' <= r && r <= 'z' || '0' <= r && r <= '9' || r > 127)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+type memoryRevisions struct {
+	s *MemoryStore
+}
+
+func (r memoryRevisions) Insert(ctx context.Context, revision model.BookRevision) error {
+	r.s.revisionsMu.Lock()
+	defer r.s.revisionsMu.Unlock()
+
+	revision.ID = r.s.IDs.NewID()
+	r.s.revisions[revision.BookID] = append(r.s.revisions[revision.BookID], revision)
+
+	return nil
+}
+
+func (r memoryRevisions) List(ctx context.Context, bookID uint64, offset, limit int) ([]model.BookRevision, int, error) {
+	r.s.revisionsMu.RLock()
+	defer r.s.revisionsMu.RUnlock()
+
+	revisions := r.s.revisions[bookID]
+	results := make([]model.BookRevision, 0, len(revisions))
+	for i := len(revisions) - 1; i >= 0; i-- {
+		results = append(results, revisions[i])
+	}
+
+	return paginate(results, offset, limit), len(results), nil
+}
+
+func (r memoryRevisions) Find(ctx context.Context, bookID, revision uint64) (model.BookRevision, error) {
+	r.s.revisionsMu.RLock()
+	defer r.s.revisionsMu.RUnlock()
+
+	for _, stored := range r.s.revisions[bookID] {
+		if stored.Revision == revision {
+			return stored, nil
+		}
+	}
+
+	return model.BookRevision{}, ErrNotFound
+}
+
+type memoryAudit struct {
+	s *MemoryStore
+}
+
+func (r memoryAudit) Insert(ctx context.Context, event model.AuditEvent) error {
+	r.s.auditMu.Lock()
+	defer r.s.auditMu.Unlock()
+
+	event.ID = r.s.IDs.NewID()
+	r.s.audit = append(r.s.audit, event)
+
+	return nil
+}
+
+func (r memoryAudit) Search(ctx context.Context, query model.AuditQuery) ([]model.AuditEvent, error) {
+	r.s.auditMu.RLock()
+	defer r.s.auditMu.RUnlock()
+
+	results := []model.AuditEvent{}
+	for i := len(r.s.audit) - 1; i >= 0 && (query.Limit == 0 || len(results) < query.Limit); i-- {
+		if event := r.s.audit[i]; auditMatches(query, event) {
+			results = append(results, event)
+		}
+	}
+
+	return results, nil
+}
+
+func auditMatches(query model.AuditQuery, event model.AuditEvent) bool {
+	switch {
+	case query.ActorID != 0 && event.ActorID != query.ActorID,
+		query.Action != "" && event.Action != query.Action,
+		query.Outcome != "" && event.Outcome != query.Outcome,
+		query.Target != "" && event.Target != query.Target,
+		query.BeforeID != 0 && event.ID >= query.BeforeID,
+		!query.Since.IsZero() && event.Time.Before(query.Since),
+		!query.Until.IsZero() && !event.Time.Before(query.Until):
+		return false
+	}
+
+	return true
+}
+
+type memoryAuthors struct {
+	s *MemoryStore
+}
+
+func (r memoryAuthors) Search(ctx context.Context, name string, offset, limit int) ([]model.Author, int, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	name = strings.ToLower(name)
+	matches := []model.Author{}
+	for _, author := range r.s.authors {
+		if name == "" || strings.Contains(strings.ToLower(author.Name), name) || containsFold(author.Aliases, name) {
+			matches = append(matches, author)
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].ID < matches[j].ID })
+
+	return paginate(matches, offset, limit), len(matches), nil
+}
+
+func (r memoryAuthors) Find(ctx context.Context, authorID uint64) (model.Author, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	author, ok := r.s.authors[authorID]
+	if !ok {
+		return model.Author{}, ErrNotFound
+	}
+
+	return author, nil
+}
+
+func (r memoryAuthors) Exist(ctx context.Context, IDs []uint64) (bool, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	for _, ID := range IDs {
+		if _, ok := r.s.authors[ID]; !ok {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+func (r memoryAuthors) Insert(ctx context.Context, item model.Author) (model.Author, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	item.ID = r.s.IDs.NewID()
+	r.s.authors[item.ID] = item
+
+	return item, nil
+}
+
+func (r memoryAuthors) Update(ctx context.Context, item model.Author) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.authors[item.ID]; !ok {
+		return ErrNotFound
+	}
+	r.s.authors[item.ID] = item
+
+	return nil
+}
+
+func (r memoryAuthors) Delete(ctx context.Context, ID uint64) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.authors[ID]; !ok {
+		return ErrNotFound
+	}
+	delete(r.s.authors, ID)
+
+	return nil
+}
+
+// containsFold reports whether one of values contains the lower case sub.
+func containsFold(values []string, sub string) bool {
+	for _, v := range values {
+		if strings.Contains(strings.ToLower(v), sub) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func paginate[T any](items []T, offset, limit int) []T {
+	if offset >= len(items) {
+		return []T{}
+	}
+	items = items[offset:]
+	if limit < len(items) {
+		items = items[:limit]
+	}
+
+	return items
+}
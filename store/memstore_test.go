@@ -0,0 +1,150 @@
+package store
+
+import (
+	"bookService/model"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryBooksSearchPages(t *testing.T) {
+	ctx := context.Background()
+	books := NewMemoryStore().Books()
+	for _, name := range []string{"Dune", "Anathem", "Dune Messiah", "Emma"} {
+		_, err := books.Insert(ctx, model.Book{Name: name, Language: "en"}, 1)
+		assert.NoError(t, err)
+	}
+
+	query := model.BookQuery{Sort: model.BookSortName, Desc: true, Limit: 2, WithTotal: true}
+	first, err := books.Search(ctx, query)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, *first.Total)
+	assert.Equal(t, "Emma", first.Items[0].Name)
+	assert.Equal(t, "Dune Messiah", first.Items[1].Name)
+
+	query.Cursor = first.NextCursor
+	second, err := books.Search(ctx, query)
+	assert.NoError(t, err)
+	assert.Equal(t, "Dune", second.Items[0].Name)
+	assert.Equal(t, "Anathem", second.Items[1].Name)
+	assert.Empty(t, second.NextCursor)
+
+	found, err := books.Search(ctx, model.BookQuery{Text: "messiah", Limit: 10})
+	assert.NoError(t, err)
+	assert.Len(t, found.Items, 1)
+}
+
+func TestMemoryStoreErrors(t *testing.T) {
+	ctx := context.Background()
+	memory := NewMemoryStore()
+
+	_, err := memory.Books().Find(ctx, 1)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	book, err := memory.Books().Insert(ctx, model.Book{Name: "Dune", ISBN: "9780441013593"}, 1)
+	assert.NoError(t, err)
+	updated, err := memory.Books().Update(ctx, book, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, book.Version+1, updated.Version)
+	_, err = memory.Books().Update(ctx, book, 1)
+	assert.ErrorIs(t, err, ErrVersionConflict)
+	assert.ErrorIs(t, memory.Books().Delete(ctx, book.ID, book.Version, 1), ErrVersionConflict)
+	_, err = memory.Books().Insert(ctx, model.Book{Name: "Dune", ISBN: "9780441013593"}, 2)
+	assert.ErrorIs(t, err, ErrDuplicate)
+
+	_, err = memory.Users().Insert(ctx, model.User{Login: "reader"})
+	assert.NoError(t, err)
+	_, err = memory.Users().Insert(ctx, model.User{Login: "reader"})
+	assert.ErrorIs(t, err, ErrDuplicate)
+	assert.ErrorIs(t, memory.Users().SetLocked(ctx, 42, true), ErrNotFound)
+
+	_, err = memory.Users().VerifyRecoveryToken(ctx, "")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestMemoryBooksTrash(t *testing.T) {
+	ctx := context.Background()
+	books := NewMemoryStore().Books()
+	book, err := books.Insert(ctx, model.Book{Name: "Dune"}, 1)
+	assert.NoError(t, err)
+	other, err := books.Insert(ctx, model.Book{Name: "Emma"}, 2)
+	assert.NoError(t, err)
+
+	assert.NoError(t, books.Delete(ctx, book.ID, book.Version, 3))
+	assert.NoError(t, books.Delete(ctx, other.ID, other.Version, 2))
+	_, err = books.Find(ctx, book.ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+	all, err := books.GetAll(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, all)
+
+	trash, total, err := books.Trash(ctx, 1, 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, total)
+	assert.Equal(t, uint64(3), trash[0].DeletedBy)
+	_, total, _ = books.Trash(ctx, 0, 0, 10)
+	assert.Equal(t, 2, total)
+
+	restored, err := books.Restore(ctx, book.ID, 1)
+	assert.NoError(t, err)
+	assert.Nil(t, restored.DeletedAt)
+	assert.Equal(t, book.Version+2, restored.Version)
+	_, err = books.Restore(ctx, book.ID, 1)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	n, err := books.Purge(ctx, time.Now().Add(time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	_, err = books.FindDeleted(ctx, other.ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = books.Find(ctx, book.ID)
+	assert.NoError(t, err)
+}
+
+func TestMemoryPurgeKeepsHistory(t *testing.T) {
+	ctx := context.Background()
+	memory := NewMemoryStore()
+	book, err := memory.Books().Insert(ctx, model.Book{Name: "Dune", ISBN: "9780441013593"}, 1)
+	assert.NoError(t, err)
+	assert.NoError(t, memory.Books().Delete(ctx, book.ID, book.Version, 1))
+
+	n, err := memory.Books().Purge(ctx, time.Now().Add(time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	revisions, total, err := memory.Revisions().List(ctx, book.ID, 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, total)
+	tombstone := revisions[0]
+	assert.Equal(t, model.RevisionPurge, tombstone.Action)
+	assert.Equal(t, book.Version+2, tombstone.Revision)
+	assert.Equal(t, model.Book{ID: book.ID, Version: tombstone.Revision}, tombstone.Snapshot)
+	assert.Contains(t, tombstone.Changed, "name")
+	assert.Contains(t, tombstone.Changed, "deleted_at")
+	assert.Equal(t, model.RevisionCreate, revisions[2].Action)
+}
+
+func TestMemoryTokensUse(t *testing.T) {
+	ctx := context.Background()
+	tokens := NewMemoryStore().Tokens()
+	expires := time.Now().Add(time.Hour)
+	assert.NoError(t, tokens.Insert(ctx, model.RefreshToken{ID: "a", FamilyID: "f", UserID: 1, ExpiresAt: expires}))
+	assert.NoError(t, tokens.Insert(ctx, model.RefreshToken{ID: "b", FamilyID: "f", UserID: 1, ExpiresAt: expires}))
+
+	token, err := tokens.Use(ctx, "a")
+	assert.NoError(t, err)
+	assert.NotNil(t, token.UsedAt)
+
+	token, err = tokens.Use(ctx, "a")
+	assert.ErrorIs(t, err, ErrTokenReused)
+	assert.Equal(t, "f", token.FamilyID)
+
+	assert.NoError(t, tokens.RevokeFamily(ctx, "f"))
+	_, err = tokens.Use(ctx, "b")
+	assert.ErrorIs(t, err, ErrTokenReused)
+
+	_, err = tokens.Use(ctx, "missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
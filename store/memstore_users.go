@@ -0,0 +1,328 @@
+package store
+
+import (
+	"bookService/model"
+	"context"
+	"sort"
+	"strings"
+	"time"
+)
+
+type memoryUsers struct {
+	s *MemoryStore
+}
+
+func (r memoryUsers) GetAll(ctx context.Context) ([]model.User, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	results := make([]model.User, 0, len(r.s.users))
+	for _, user := range r.s.users {
+		results = append(results, user)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].ID < results[j].ID })
+
+	return results, nil
+}
+
+func (r memoryUsers) Search(ctx context.Context, login string, offset, limit int) ([]model.User, int, error) {
+	all, _ := r.GetAll(ctx)
+
+	login = strings.ToLower(login)
+	matches := []model.User{}
+	for _, user := range all {
+		if strings.Contains(strings.ToLower(user.Login), login) {
+			matches = append(matches, user)
+		}
+	}
+
+	return paginate(matches, offset, limit), len(matches), nil
+}
+
+func (r memoryUsers) Find(ctx context.Context, userID uint64) (model.User, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	user, ok := r.s.users[userID]
+	if !ok {
+		return model.User{}, ErrNotFound
+	}
+
+	return user, nil
+}
+
+func (r memoryUsers) GetByLogin(ctx context.Context, login string) (*model.User, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	for _, user := range r.s.users {
+		if user.Login == login {
+			return &user, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+func (r memoryUsers) Insert(ctx context.Context, item model.User) (model.User, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, user := range r.s.users {
+		if user.Login == item.Login {
+			return model.User{}, ErrDuplicate
+		}
+	}
+
+	item.ID = r.s.IDs.NewID()
+	r.s.users[item.ID] = item
+
+	return item, nil
+}
+
+func (r memoryUsers) Update(ctx context.Context, item model.User) error {
+	return r.modify(item.ID, func(user *model.User) {
+		*user = item
+	})
+}
+
+func (r memoryUsers) Delete(ctx context.Context, ID uint64) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.users[ID]; !ok {
+		return ErrNotFound
+	}
+	delete(r.s.users, ID)
+
+	return nil
+}
+
+func (r memoryUsers) SaveRecoveryToken(ctx context.Context, userID uint64, recoveryToken string) error {
+	return r.modify(userID, func(user *model.User) {
+		user.RecoveryToken = recoveryToken
+	})
+}
+
+func (r memoryUsers) VerifyRecoveryToken(ctx context.Context, recoveryToken string) (uint64, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	if recoveryToken == "" {
+		return 0, ErrNotFound
+	}
+	for _, user := range r.s.users {
+		if user.RecoveryToken == recoveryToken {
+			return user.ID, nil
+		}
+	}
+
+	return 0, ErrNotFound
+}
+
+func (r memoryUsers) SetPassword(ctx context.Context, userID uint64, hashedPassword string) error {
+	return r.modify(userID, func(user *model.User) {
+		user.Password = hashedPassword
+		user.RecoveryToken = ""
+		user.PasswordResetRequired = false
+	})
+}
+
+func (r memoryUsers) SetRole(ctx context.Context, userID uint64, role model.Role) error {
+	return r.modify(userID, func(user *model.User) {
+		user.Role = role
+	})
+}
+
+func (r memoryUsers) SetLocked(ctx context.Context, userID uint64, locked bool) error {
+	return r.modify(userID, func(user *model.User) {
+		user.Locked = locked
+	})
+}
+
+func (r memoryUsers) RequirePasswordReset(ctx context.Context, userID uint64, recoveryToken string) error {
+	return r.modify(userID, func(user *model.User) {
+		user.PasswordResetRequired = true
+		user.RecoveryToken = recoveryToken
+	})
+}
+
+func (r memoryUsers) modify(userID uint64, change func(user *model.User)) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	user, ok := r.s.users[userID]
+	if !ok {
+		return ErrNotFound
+	}
+	change(&user)
+	r.s.users[userID] = user
+
+	return nil
+}
+
+type memoryTokens struct {
+	s *MemoryStore
+}
+
+func (r memoryTokens) Insert(ctx context.Context, token model.RefreshToken) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.tokens[token.ID]; ok {
+		return ErrDuplicate
+	}
+	r.s.tokens[token.ID] = token
+
+	return nil
+}
+
+func (r memoryTokens) Find(ctx context.Context, ID string) (model.RefreshToken, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	token, ok := r.s.tokens[ID]
+	if !ok || token.ExpiresAt.Before(time.Now()) {
+		return model.RefreshToken{}, ErrNotFound
+	}
+
+	return token, nil
+}
+
+func (r memoryTokens) Use(ctx context.Context, ID string) (model.RefreshToken, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	token, ok := r.s.tokens[ID]
+	if !ok || token.ExpiresAt.Before(time.Now()) {
+		return model.RefreshToken{}, ErrNotFound
+	}
+	if token.UsedAt != nil || token.Revoked {
+		return token, ErrTokenReused
+	}
+
+	now := time.Now()
+	token.UsedAt = &now
+	r.s.tokens[ID] = token
+
+	return token, nil
+}
+
+func (r memoryTokens) RevokeFamily(ctx context.Context, familyID string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for ID, token := range r.s.tokens {
+		if token.FamilyID == familyID {
+			token.Revoked = true
+			r.s.tokens[ID] = token
+		}
+	}
+
+	return nil
+}
+
+func (r memoryTokens) RevokeUser(ctx context.Context, userID uint64) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for ID, token := range r.s.tokens {
+		if token.UserID == userID {
+			token.Revoked = true
+			r.s.tokens[ID] = token
+		}
+	}
+
+	return nil
+}
+
+type memorySessions struct {
+	s *MemoryStore
+}
+
+func (r memorySessions) Insert(ctx context.Context, session model.Session) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.sessions[session.ID]; ok {
+		return ErrDuplicate
+	}
+	r.s.sessions[session.ID] = session
+
+	return nil
+}
+
+func (r memorySessions) Find(ctx context.Context, ID string) (model.Session, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	session, ok := r.s.sessions[ID]
+	if !ok || session.ExpiresAt.Before(time.Now()) {
+		return model.Session{}, ErrNotFound
+	}
+
+	return session, nil
+}
+
+func (r memorySessions) GetActiveByUser(ctx context.Context, userID uint64) ([]model.Session, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	now := time.Now()
+	results := []model.Session{}
+	for _, session := range r.s.sessions {
+		if session.UserID == userID && session.RevokedAt == nil && session.ExpiresAt.After(now) {
+			results = append(results, session)
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].LastRefreshAt.After(results[j].LastRefreshAt)
+	})
+
+	return results, nil
+}
+
+func (r memorySessions) Touch(ctx context.Context, ID string, refreshedAt, expiresAt time.Time) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	session, ok := r.s.sessions[ID]
+	if !ok {
+		return ErrNotFound
+	}
+	session.LastRefreshAt = refreshedAt
+	session.ExpiresAt = expiresAt
+	r.s.sessions[ID] = session
+
+	return nil
+}
+
+func (r memorySessions) Revoke(ctx context.Context, ID string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	session, ok := r.s.sessions[ID]
+	if !ok {
+		return ErrNotFound
+	}
+	now := time.Now()
+	session.RevokedAt = &now
+	r.s.sessions[ID] = session
+
+	return nil
+}
+
+func (r memorySessions) RevokeUser(ctx context.Context, userID uint64) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	now := time.Now()
+	for ID, session := range r.s.sessions {
+		if session.UserID == userID && session.RevokedAt == nil {
+			session.RevokedAt = &now
+			r.s.sessions[ID] = session
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,480 @@
+package store
+
+import (
+	"bookService/logging"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+const collectionSchemaMigrations = "schema_migrations"
+
+var ErrIrreversible = errors.New("migration cannot be reverted")
+
+// Migration is one step of the schema. Up and Down must be idempotent, a
+// migration interrupted before it was recorded runs again in full.
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(ctx context.Context, db *mongo.Database) error
+	// Down is nil for migrations that cannot be reverted.
+	Down func(ctx context.Context, db *mongo.Database) error
+}
+
+type MigrationRecord struct {
+	Version     int       `bson:"_id"`
+	Description string    `bson:"description"`
+	AppliedAt   time.Time `bson:"applied_at"`
+}
+
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// migrations is the ordered schema history. Only ever append to it.
+var migrations = []Migration{
+	{
+		Version:     1,
+		Description: "create indexes",
+		Up:          createIndexes,
+		Down:        dropIndexes,
+	},
+	{
+		Version:     2,
+		Description: "move book uploader from author_id to owner_id",
+		Up:          migrateBookOwners,
+	},
+	{
+		Version:     3,
+		Description: "remove placeholder users and trash placeholder books inserted on every boot",
+		Up:          removePlaceholders,
+	},
+	{
+		Version:     4,
+		Description: "drop the auto increment counters replaced by generated ids",
+		Up:          dropCounters,
+	},
+	{
+		Version:     5,
+		Description: "start every book at version 1",
+		Up:          addBookVersions,
+		Down:        removeBookVersions,
+	},
+	{
+		Version:     6,
+		Description: "index trashed books",
+		Up:          createTrashIndexes,
+		Down:        dropTrashIndexes,
+	},
+	{
+		Version:     7,
+		Description: "index book revisions",
+		Up:          createRevisionIndexes,
+		Down:        dropRevisionIndexes,
+	},
+	{
+		Version:     8,
+		Description: "index audit events",
+		Up:          createAuditIndexes,
+		Down:        dropAuditIndexes,
+	},
+	{
+		Version:     9,
+		Description: "keep isbns unique outside the trash only",
+		Up:          createLiveISBNIndex,
+		Down:        dropLiveISBNIndex,
+	},
+}
+
+// prepare applies the pending migrations, or only warns about them if
+// migrate is false.
+func (s *MongoStore) prepare(ctx context.Context, migrate bool) error {
+	if migrate {
+		_, err := s.MigrateUp(ctx)
+
+		return err
+	}
+
+	statuses, err := s.MigrationStatus(ctx)
+	if err != nil {
+		return err
+	}
+	for _, status := range statuses {
+		if status.AppliedAt == nil {
+			logging.FromContext(ctx).Warn("prepare migration is pending, run migrate up",
+				"version", status.Version, "description", status.Description)
+		}
+	}
+
+	return nil
+}
+
+// checkMigrations fails while migrations are pending.
+func (s *MongoStore) checkMigrations(ctx context.Context) error {
+	statuses, err := s.MigrationStatus(ctx)
+	if err != nil {
+		return err
+	}
+
+	var pending []string
+	for _, status := range statuses {
+		if status.AppliedAt == nil {
+			pending = append(pending, strconv.Itoa(status.Version))
+		}
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("pending migrations %s", strings.Join(pending, ", "))
+	}
+
+	return nil
+}
+
+// MigrateUp applies every pending migration in order and returns the ones
+// it applied.
+func (s *MongoStore) MigrateUp(ctx context.Context) ([]Migration, error) {
+	applied, err := s.appliedMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	done := []Migration{}
+	for _, migration := range migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+
+		logging.FromContext(ctx).Info("MigrateUp", "version", migration.Version, "description", migration.Description)
+		if err := migration.Up(ctx, s.conn); err != nil {
+			return done, fmt.Errorf("migration %d up: %w", migration.Version, err)
+		}
+
+		_, err := s.conn.Collection(collectionSchemaMigrations).InsertOne(ctx, MigrationRecord{
+			Version:     migration.Version,
+			Description: migration.Description,
+			AppliedAt:   time.Now(),
+		})
+		// Another instance applied it concurrently, which is harmless as
+		// migrations are idempotent.
+		if err != nil && !mongo.IsDuplicateKeyError(err) {
+			return done, fmt.Errorf("migration %d record: %w", migration.Version, err)
+		}
+		done = append(done, migration)
+	}
+
+	return done, nil
+}
+
+// MigrateDown reverts the last steps applied migrations, newest first.
+func (s *MongoStore) MigrateDown(ctx context.Context, steps int) ([]Migration, error) {
+	applied, err := s.appliedMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	done := []Migration{}
+	for i := len(migrations) - 1; i >= 0 && len(done) < steps; i-- {
+		migration := migrations[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+		if migration.Down == nil {
+			return done, fmt.Errorf("migration %d %s: %w", migration.Version, migration.Description, ErrIrreversible)
+		}
+
+		logging.FromContext(ctx).Info("MigrateDown", "version", migration.Version, "description", migration.Description)
+		if err := migration.Down(ctx, s.conn); err != nil {
+			return done, fmt.Errorf("migration %d down: %w", migration.Version, err)
+		}
+
+		_, err := s.conn.Collection(collectionSchemaMigrations).DeleteOne(ctx, obj{"_id": migration.Version})
+		if err != nil {
+			return done, fmt.Errorf("migration %d unrecord: %w", migration.Version, err)
+		}
+		done = append(done, migration)
+	}
+
+	return done, nil
+}
+
+// MigrationStatus lists every known migration with the time it was applied,
+// nil if it is pending.
+func (s *MongoStore) MigrationStatus(ctx context.Context) ([]MigrationStatus, error) {
+	applied, err := s.appliedMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(migrations))
+	for _, migration := range migrations {
+		status := MigrationStatus{Migration: migration}
+		if record, ok := applied[migration.Version]; ok {
+			status.AppliedAt = &record.AppliedAt
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+func (s *MongoStore) appliedMigrations(ctx context.Context) (map[int]MigrationRecord, error) {
+	records := []MigrationRecord{}
+	cursor, err := s.conn.Collection(collectionSchemaMigrations).Find(ctx, obj{})
+	if err == nil {
+		err = cursor.All(ctx, &records)
+	}
+	if err != nil {
+		logError(ctx, "appliedMigrations Find", err)
+
+		return nil, err
+	}
+
+	applied := make(map[int]MigrationRecord, len(records))
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+
+	return applied, nil
+}
+
+var indexes = map[string][]mongo.IndexModel{
+	collectionBooks: {
+		{
+			Keys: keys("$text:name", "$text:subtitle", "$text:description", "$text:subjects"),
+			Options: options.Index().
+				SetName("books_text_v2").
+				SetWeights(obj{"name": 10, "subtitle": 5, "subjects": 3, "description": 1}),
+		},
+		{Keys: keys("isbn"), Options: options.Index().SetUnique(true).SetSparse(true)},
+		{Keys: keys("language", "_id")},
+		{Keys: keys("genres", "_id")},
+		{Keys: keys("name", "_id")},
+		{Keys: keys("contributors.author_id", "_id")},
+		{Keys: keys("contributors.author_id", "name", "_id")},
+		{Keys: keys("owner_id", "_id")},
+	},
+	collectionUsers: {
+		{Keys: keys("login"), Options: options.Index().SetUnique(true)},
+	},
+	collectionRefreshTokens: {
+		{Keys: keys("family_id")},
+		{Keys: keys("user_id")},
+		{Keys: keys("expires_at"), Options: options.Index().SetExpireAfterSeconds(1)},
+	},
+	collectionAuthors: {
+		{Keys: keys("name", "_id")},
+		{Keys: keys("aliases")},
+	},
+	collectionSessions: {
+		{Keys: keys("user_id", "-last_refresh_at")},
+		{Keys: keys("expires_at"), Options: options.Index().SetExpireAfterSeconds(1)},
+	},
+}
+
+func createIndexes(ctx context.Context, db *mongo.Database) error {
+	// Only one text index is allowed per collection, so the one covering the
+	// name alone has to go before the wider one can be built.
+	if err := db.Collection(collectionBooks).Indexes().DropOne(ctx, "books_text"); err != nil {
+		logging.FromContext(ctx).Debug("createIndexes DropOne books_text", "err", err)
+	}
+
+	for collection, models := range indexes {
+		if _, err := db.Collection(collection).Indexes().CreateMany(ctx, models); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func dropIndexes(ctx context.Context, db *mongo.Database) error {
+	for collection := range indexes {
+		if err := db.Collection(collection).Indexes().DropAll(ctx); err != nil {
+			logging.FromContext(ctx).Warn("dropIndexes DropAll", "collection", collection, "err", err)
+		}
+	}
+
+	return nil
+}
+
+// migrateBookOwners moves the uploader id of books stored before authors
+// existed from author_id to owner_id. Those books have no contributors yet.
+func migrateBookOwners(ctx context.Context, db *mongo.Database) error {
+	result, err := db.Collection(collectionBooks).UpdateMany(ctx,
+		obj{"author_id": obj{"$exists": true}, "owner_id": obj{"$exists": false}},
+		obj{"$rename": obj{"author_id": "owner_id"}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.ModifiedCount > 0 {
+		logging.FromContext(ctx).Info("migrateBookOwners moved author_id to owner_id", "books", result.ModifiedCount)
+	}
+
+	for _, name := range []string{"author_id_1__id_1", "author_id_1_name_1__id_1"} {
+		if err := db.Collection(collectionBooks).Indexes().DropOne(ctx, name); err != nil {
+			logging.FromContext(ctx).Debug("migrateBookOwners DropOne", "index", name, "err", err)
+		}
+	}
+
+	return nil
+}
+
+// removePlaceholders deletes the passwordless users named "Book N" older
+// versions inserted on every start. Their ids came from a counter, so the
+// matching bare books are told apart by their fields alone and a real book
+// may look the same. Those are moved to the trash rather than deleted,
+// they can be restored until the trash is purged.
+func removePlaceholders(ctx context.Context, db *mongo.Database) error {
+	names := []string{"Book 1", "Book 2", "Book 3"}
+
+	users, err := db.Collection(collectionUsers).DeleteMany(ctx, obj{
+		"login":    obj{"$in": names},
+		"password": obj{"$in": []interface{}{"", nil}},
+	})
+	if err != nil {
+		return err
+	}
+
+	books, err := db.Collection(collectionBooks).UpdateMany(ctx, obj{
+		"name":         obj{"$in": names},
+		"owner_id":     obj{"$in": []uint64{3, 4}},
+		"isbn":         obj{"$exists": false},
+		"contributors": obj{"$exists": false},
+		"description":  obj{"$exists": false},
+		"deleted_at":   notDeleted,
+	}, obj{"$set": obj{"deleted_at": time.Now().UTC()}})
+	if err != nil {
+		return err
+	}
+
+	logging.FromContext(ctx).Info("removePlaceholders deleted placeholder users and trashed placeholder books",
+		"users", users.DeletedCount, "books", books.ModifiedCount)
+
+	return nil
+}
+
+// dropCounters removes the collection the ids were counted in before they
+// were generated in-process.
+func dropCounters(ctx context.Context, db *mongo.Database) error {
+	return db.Collection("ai").Drop(ctx)
+}
+
+// addBookVersions gives books stored before updates were conditional the
+// version Insert starts at.
+func addBookVersions(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection(collectionBooks).UpdateMany(ctx,
+		obj{"version": obj{"$exists": false}},
+		obj{"$set": obj{"version": 1}},
+	)
+
+	return err
+}
+
+func removeBookVersions(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection(collectionBooks).UpdateMany(ctx, obj{}, obj{"$unset": obj{"version": ""}})
+
+	return err
+}
+
+// trashIndexes only cover trashed books, the trash listing and the purge
+// are the only queries looking for them.
+var trashIndexes = []mongo.IndexModel{
+	{
+		Keys: keys("deleted_at"),
+		Options: options.Index().SetName("books_trash").
+			SetPartialFilterExpression(obj{"deleted_at": obj{"$exists": true}}),
+	},
+	{
+		Keys: keys("owner_id", "-deleted_at"),
+		Options: options.Index().SetName("books_trash_owner").
+			SetPartialFilterExpression(obj{"deleted_at": obj{"$exists": true}}),
+	},
+}
+
+func createTrashIndexes(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection(collectionBooks).Indexes().CreateMany(ctx, trashIndexes)
+
+	return err
+}
+
+func dropTrashIndexes(ctx context.Context, db *mongo.Database) error {
+	for _, name := range []string{"books_trash", "books_trash_owner"} {
+		if err := db.Collection(collectionBooks).Indexes().DropOne(ctx, name); err != nil {
+			logging.FromContext(ctx).Warn("dropTrashIndexes DropOne", "index", name, "err", err)
+		}
+	}
+
+	return nil
+}
+
+func createRevisionIndexes(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection(collectionBookRevisions).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    keys("book_id", "-revision"),
+		Options: options.Index().SetUnique(true),
+	})
+
+	return err
+}
+
+func dropRevisionIndexes(ctx context.Context, db *mongo.Database) error {
+	return db.Collection(collectionBookRevisions).Indexes().DropAll(ctx)
+}
+
+// auditIndexes serve the filters of the admin audit search, newest first.
+var auditIndexes = []mongo.IndexModel{
+	{Keys: keys("actor_id", "-_id")},
+	{Keys: keys("action", "-_id")},
+	{Keys: keys("target", "-_id")},
+	{Keys: keys("time")},
+}
+
+func createAuditIndexes(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection(collectionAuditEvents).Indexes().CreateMany(ctx, auditIndexes)
+
+	return err
+}
+
+func dropAuditIndexes(ctx context.Context, db *mongo.Database) error {
+	return db.Collection(collectionAuditEvents).Indexes().DropAll(ctx)
+}
+
+// liveISBNIndex keeps isbns unique among the books outside the trash, so a
+// trashed book does not block adding its isbn again. It replaces the sparse
+// isbn_1 index of the first migration, partial indexes cannot be sparse.
+var liveISBNIndex = mongo.IndexModel{
+	Keys: keys("isbn"),
+	Options: options.Index().SetName("books_isbn_live").SetUnique(true).
+		SetPartialFilterExpression(obj{"isbn": obj{"$exists": true}, "deleted_at": nil}),
+}
+
+func createLiveISBNIndex(ctx context.Context, db *mongo.Database) error {
+	if _, err := db.Collection(collectionBooks).Indexes().CreateOne(ctx, liveISBNIndex); err != nil {
+		return err
+	}
+	if err := db.Collection(collectionBooks).Indexes().DropOne(ctx, "isbn_1"); err != nil {
+		logging.FromContext(ctx).Debug("createLiveISBNIndex DropOne isbn_1", "err", err)
+	}
+
+	return nil
+}
+
+// dropLiveISBNIndex fails while a trashed book shares its isbn with another
+// book, the sparse index cannot be built then.
+func dropLiveISBNIndex(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection(collectionBooks).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    keys("isbn"),
+		Options: options.Index().SetUnique(true).SetSparse(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	return db.Collection(collectionBooks).Indexes().DropOne(ctx, "books_isbn_live")
+}
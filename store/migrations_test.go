@@ -0,0 +1,14 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrationsAreOrdered(t *testing.T) {
+	for i, migration := range migrations {
+		assert.Equal(t, i+1, migration.Version, migration.Description)
+		assert.NotNil(t, migration.Up, migration.Description)
+	}
+}
@@ -0,0 +1,58 @@
+package store
+
+import (
+	"bookService/tracing"
+	"context"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/v2/event"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// commandTracer turns the commands the driver sends into client spans, the
+// children of the store operation that issued them.
+type commandTracer struct {
+	spans sync.Map // request id -> trace.Span
+}
+
+func (t *commandTracer) monitor() *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Started:   t.started,
+		Succeeded: t.succeeded,
+		Failed:    t.failed,
+	}
+}
+
+func (t *commandTracer) started(ctx context.Context, e *event.CommandStartedEvent) {
+	attrs := []attribute.KeyValue{
+		attribute.String("db.system", "mongodb"),
+		attribute.String("db.name", e.DatabaseName),
+		attribute.String("db.operation", e.CommandName),
+	}
+	// The first element of a command names its collection, as in
+	// {find: "books", ...}.
+	if elem, err := e.Command.IndexErr(0); err == nil {
+		if collection, ok := elem.Value().StringValueOK(); ok {
+			attrs = append(attrs, attribute.String("db.mongodb.collection", collection))
+		}
+	}
+
+	_, span := tracing.Start(ctx, "mongo."+e.CommandName,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+	t.spans.Store(e.RequestID, span)
+}
+
+func (t *commandTracer) succeeded(ctx context.Context, e *event.CommandSucceededEvent) {
+	if span, ok := t.spans.LoadAndDelete(e.RequestID); ok {
+		span.(trace.Span).End()
+	}
+}
+
+func (t *commandTracer) failed(ctx context.Context, e *event.CommandFailedEvent) {
+	if span, ok := t.spans.LoadAndDelete(e.RequestID); ok {
+		tracing.End(span.(trace.Span), e.Failure)
+	}
+}
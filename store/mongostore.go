@@ -2,140 +2,257 @@ package store
 
 import (
 	"bookService/config"
-	"bookService/model"
+	"bookService/health"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
-	"log"
+	"os"
+	"strconv"
+	"strings"
 
-	ai "github.com/night-codes/mgo-ai"
-	"gopkg.in/mgo.v2"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"go.mongodb.org/mongo-driver/v2/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/v2/mongo/writeconcern"
 )
 
-type obj map[string]interface{}
+type obj = bson.M
 
 type MongoStore struct {
-	conn            *mgo.Database
-	BooksRepository *BooksRepository
-	UsersRepository *UsersRepository
+	client              *mongo.Client
+	conn                *mongo.Database
+	IDs                 IDGenerator
+	BooksRepository     *BooksRepository
+	RevisionsRepository *RevisionsRepository
+	UsersRepository     *UsersRepository
+	TokensRepository    *TokensRepository
+	SessionsRepository  *SessionsRepository
+	AuthorsRepository   *AuthorsRepository
+	AuditRepository     *AuditRepository
 }
 
-type Database interface {
-	GetByLogin(login string) (*model.User, error)
-	GetByID(ID uint64) (*model.User, error)
-	Insert(user model.User) error
-	SaveRecoveryToken(userID uint64, token string) error
-	VerifyRecoveryToken(token string) (uint64, error)
-}
+var _ Store = (*MongoStore)(nil)
 
 func NewMongoStore(cfg *config.Config) (*MongoStore, error) {
-	dsn := fmt.Sprintf("mongodb://%s:%d", cfg.Mongo.Host, cfg.Mongo.Port)
-	session, err := mgo.Dial(dsn)
+	opts, err := clientOptions(cfg.Mongo)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := mongo.Connect(opts)
 	if err != nil {
 		return nil, err
 	}
-	db := session.DB(cfg.Mongo.Database)
 
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Mongo.ConnectTimeout)
+	defer cancel()
+	if err := client.Ping(ctx, nil); err != nil {
+		_ = client.Disconnect(context.Background())
+
+		return nil, err
+	}
+
+	db := client.Database(cfg.Mongo.Database)
 	store := &MongoStore{
-		conn: db,
+		client: client,
+		conn:   db,
+		IDs:    newIDGenerator(cfg.Store),
 	}
 
-	initStore(db)
-	store.BooksRepository = store.Books()
-	store.UsersRepository = store.Users()
+	store.BooksRepository = NewBooksRepository(store)
+	store.RevisionsRepository = NewRevisionsRepository(store)
+	store.UsersRepository = NewUsersRepository(store)
+	store.TokensRepository = NewTokensRepository(store)
+	store.SessionsRepository = NewSessionsRepository(store)
+	store.AuthorsRepository = NewAuthorsRepository(store)
+	store.AuditRepository = NewAuditRepository(store)
 
 	return store, nil
 }
 
-func initStore(db *mgo.Database) {
-	if err := createCollections(db); err != nil {
-		log.Printf("Err: %v", err)
+func clientOptions(cfg config.MongoConfig) (*options.ClientOptions, error) {
+	opts := options.Client().
+		ApplyURI(cfg.DSN()).
+		SetMaxPoolSize(cfg.MaxPoolSize).
+		SetMinPoolSize(cfg.MinPoolSize).
+		SetConnectTimeout(cfg.ConnectTimeout).
+		SetServerSelectionTimeout(cfg.ConnectTimeout).
+		SetTimeout(cfg.Timeout).
+		SetMonitor((&commandTracer{}).monitor())
+
+	if cfg.ReadConcern != "" {
+		rc, err := readConcern(cfg.ReadConcern)
+		if err != nil {
+			return nil, err
+		}
+		opts.SetReadConcern(rc)
+	}
+	if cfg.WriteConcern != "" {
+		opts.SetWriteConcern(writeConcern(cfg.WriteConcern))
+	}
+
+	if cfg.AuthSource != "" && opts.Auth != nil {
+		opts.Auth.AuthSource = cfg.AuthSource
+	}
+	if cfg.ReplicaSet != "" {
+		opts.SetReplicaSet(cfg.ReplicaSet)
+	}
+	if cfg.DirectConnection {
+		opts.SetDirect(true)
+	}
+	if cfg.UseTLS() {
+		tlsConfig, err := tlsConfig(cfg)
+		if err != nil {
+			return nil, err
+		}
+		opts.SetTLSConfig(tlsConfig)
 	}
 
-	if err := insertDocuments(db); err != nil {
-		log.Printf("Err: %v", err)
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("mongo connection options: %w", err)
 	}
 
-	log.Println("Data successfully inserted into MongoDB collection.")
+	return opts, nil
 }
 
-func createCollections(db *mgo.Database) error {
-	index := mgo.Index{
-		Key:    []string{"_id"},
-		Unique: true,
-	}
-	err := db.C("books").EnsureIndex(index)
-	if err != nil {
-		return err
+func tlsConfig(cfg config.MongoConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if cfg.TLSCAFile != "" {
+		data, err := os.ReadFile(cfg.TLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("MONGO_TLS_CA_FILE: %w", err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("MONGO_TLS_CA_FILE: no PEM certificates in %s", cfg.TLSCAFile)
+		}
 	}
-	index2 := mgo.Index{
-		Key:    []string{"_id"},
-		Unique: true,
+
+	if cfg.TLSCertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.TLSCertFile, cfg.TLSKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("MONGO_TLS_CERT_FILE/MONGO_TLS_KEY_FILE: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
 	}
-	err = db.C("users").EnsureIndex(index2)
 
-	return err
+	return tlsConfig, nil
 }
 
-func insertDocuments(db *mgo.Database) error {
-	var err error
-	books := []model.Book{
-		{Name: "Book 1", AuthorID: 4},
-		{Name: "Book 2", AuthorID: 4},
-		{Name: "Book 3", AuthorID: 3},
+func readConcern(level string) (*readconcern.ReadConcern, error) {
+	switch strings.ToLower(level) {
+	case "local":
+		return readconcern.Local(), nil
+	case "majority":
+		return readconcern.Majority(), nil
+	case "linearizable":
+		return readconcern.Linearizable(), nil
+	case "available":
+		return readconcern.Available(), nil
+	case "snapshot":
+		return readconcern.Snapshot(), nil
 	}
 
-	for _, book := range books {
-		ai.Connect(db.C("ai"))
-		book.ID = ai.Next("books")
-		err = db.C("books").Insert(book)
-		if err != nil {
-			log.Printf("Err: %v", err)
+	return nil, fmt.Errorf("unknown mongo read concern %q", level)
+}
 
-			return err
-		}
+func writeConcern(w string) *writeconcern.WriteConcern {
+	if strings.EqualFold(w, "majority") {
+		return writeconcern.Majority()
 	}
-	users := []model.User{
-		{Login: "Book 1", Role: "Author"},
-		{Login: "Book 2", Role: "Author"},
-		{Login: "Book 3", Role: "Author"},
+	if n, err := strconv.Atoi(w); err == nil {
+		return &writeconcern.WriteConcern{W: n}
 	}
 
-	for _, user := range users {
-		ai.Connect(db.C("ai"))
-		user.ID = ai.Next("users")
-		err = db.C("users").Insert(user)
-		if err != nil {
-			log.Printf("Err: %v", err)
+	return writeconcern.Custom(w)
+}
 
-			return err
+// keys builds an ordered index or sort specification. A "-" prefix sorts
+// descending and a "$text:" prefix makes a text index field.
+func keys(fields ...string) bson.D {
+	spec := make(bson.D, 0, len(fields))
+	for _, field := range fields {
+		switch {
+		case strings.HasPrefix(field, "-"):
+			spec = append(spec, bson.E{Key: field[1:], Value: -1})
+		case strings.HasPrefix(field, "$text:"):
+			spec = append(spec, bson.E{Key: strings.TrimPrefix(field, "$text:"), Value: "text"})
+		default:
+			spec = append(spec, bson.E{Key: field, Value: 1})
 		}
 	}
 
-	index := mgo.Index{
-		Key:    []string{"login"},
-		Unique: true,
-	}
-	err = db.C("users").EnsureIndex(index)
-	if err != nil {
-		log.Printf("Err: %v", err)
+	return spec
+}
 
-		return err
+func (s *MongoStore) Books() BookStore {
+	return s.BooksRepository
+}
+
+func (s *MongoStore) Revisions() RevisionStore {
+	return s.RevisionsRepository
+}
+
+func (s *MongoStore) Users() UserStore {
+	return s.UsersRepository
+}
+
+func (s *MongoStore) Tokens() TokenStore {
+	return s.TokensRepository
+}
+
+func (s *MongoStore) Sessions() SessionStore {
+	return s.SessionsRepository
+}
+
+func (s *MongoStore) Authors() AuthorStore {
+	return s.AuthorsRepository
+}
+
+func (s *MongoStore) Audit() AuditStore {
+	return s.AuditRepository
+}
+
+// Checks reports the database unready if it cannot be reached or is not
+// migrated to the schema this build expects.
+func (s *MongoStore) Checks() []health.Check {
+	return []health.Check{
+		{Name: "mongo", Probe: func(ctx context.Context) error {
+			return s.client.Ping(ctx, nil)
+		}},
+		{Name: "migrations", Probe: s.checkMigrations},
 	}
+}
 
-	return err
+// Close disconnects the client, waiting for in-flight operations until ctx
+// is done.
+func (s *MongoStore) Close(ctx context.Context) error {
+	return s.client.Disconnect(ctx)
 }
 
-func (s *MongoStore) Books() *BooksRepository {
-	if s.BooksRepository == nil {
-		s.BooksRepository = NewBooksRepository(s)
+// mongoErr translates driver errors into the backend independent errors of
+// this package.
+func mongoErr(err error) error {
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return ErrNotFound
+	case mongo.IsDuplicateKeyError(err):
+		return ErrDuplicate
 	}
 
-	return s.BooksRepository
+	return err
 }
 
-func (s *MongoStore) Users() *UsersRepository {
-	if s.UsersRepository == nil {
-		s.UsersRepository = NewUsersRepository(s)
+// matched turns an update or delete that matched no document into
+// ErrNotFound, as the driver does not report it as an error.
+func matched(n int64) error {
+	if n == 0 {
+		return ErrNotFound
 	}
 
-	return s.UsersRepository
+	return nil
 }
@@ -0,0 +1,53 @@
+package store
+
+import (
+	"bookService/config"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestKeys(t *testing.T) {
+	assert.Equal(t, bson.D{
+		{Key: "user_id", Value: 1},
+		{Key: "last_refresh_at", Value: -1},
+		{Key: "name", Value: "text"},
+	}, keys("user_id", "-last_refresh_at", "$text:name"))
+}
+
+func TestClientOptionsConcerns(t *testing.T) {
+	cfg := config.MongoConfig{Host: "localhost", Port: 27017, ReadConcern: "majority", WriteConcern: "2"}
+	opts, err := clientOptions(cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, "majority", opts.ReadConcern.Level)
+	assert.Equal(t, 2, opts.WriteConcern.W)
+
+	cfg.ReadConcern = "eventual"
+	_, err = clientOptions(cfg)
+	assert.Error(t, err)
+}
+
+func TestClientOptionsConnection(t *testing.T) {
+	cfg := config.MongoConfig{
+		Host: "db", Port: 27017, Username: "app", Password: "secret",
+		AuthSource: "admin", ReplicaSet: "rs0", DirectConnection: true,
+	}
+	opts, err := clientOptions(cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, "app", opts.Auth.Username)
+	assert.Equal(t, "admin", opts.Auth.AuthSource)
+	assert.Equal(t, "rs0", *opts.ReplicaSet)
+	assert.True(t, *opts.Direct)
+	assert.Nil(t, opts.TLSConfig)
+
+	cfg.URI = "mongodb://db1:27017,db2:27017"
+	cfg.Username, cfg.Password = "", ""
+	_, err = clientOptions(cfg)
+	assert.Error(t, err)
+
+	cfg.DirectConnection = false
+	cfg.TLSCAFile = "mongostore_test.go"
+	_, err = clientOptions(cfg)
+	assert.ErrorContains(t, err, "MONGO_TLS_CA_FILE")
+}
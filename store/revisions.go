@@ -0,0 +1,32 @@
+package store
+
+import (
+	"bookService/model"
+	"context"
+	"time"
+)
+
+// recordRevision stores the revision of a change. It is called before the
+// change is made, a change whose revision is not stored must not be made.
+func recordRevision(ctx context.Context, revisions RevisionStore, action model.RevisionAction,
+	before, after model.Book, actorID uint64) error {
+	return revisions.Insert(ctx, newRevision(action, before, after, actorID))
+}
+
+func newRevision(action model.RevisionAction, before, after model.Book, actorID uint64) model.BookRevision {
+	return model.BookRevision{
+		BookID:    after.ID,
+		Revision:  after.Version,
+		Action:    action,
+		ActorID:   actorID,
+		CreatedAt: time.Now(),
+		Changed:   model.ChangedFields(before, after),
+		Snapshot:  after,
+	}
+}
+
+// purgeRevision is the tombstone left in the history of a purged book. Every
+// field of the book is reported as removed.
+func purgeRevision(book model.Book) model.BookRevision {
+	return newRevision(model.RevisionPurge, book, model.Book{ID: book.ID, Version: book.Version + 1}, 0)
+}
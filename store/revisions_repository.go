@@ -0,0 +1,85 @@
+package store
+
+import (
+	"bookService/model"
+	"context"
+
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+const collectionBookRevisions = "book_revisions"
+
+type RevisionsRepository struct {
+	store *MongoStore
+}
+
+func NewRevisionsRepository(store *MongoStore) *RevisionsRepository {
+	return &RevisionsRepository{
+		store: store,
+	}
+}
+
+func (r *RevisionsRepository) Insert(ctx context.Context, revision model.BookRevision) error {
+	_, err := r.insert(ctx, revision)
+
+	return err
+}
+
+// insert stores the revision and returns its id.
+func (r *RevisionsRepository) insert(ctx context.Context, revision model.BookRevision) (uint64, error) {
+	revision.ID = r.store.IDs.NewID()
+	_, err := r.store.conn.Collection(collectionBookRevisions).InsertOne(ctx, revision)
+	if err != nil {
+		logError(ctx, "insert InsertOne", err)
+	}
+
+	return revision.ID, mongoErr(err)
+}
+
+func (r *RevisionsRepository) List(ctx context.Context, bookID uint64, offset, limit int) ([]model.BookRevision, int, error) {
+	filter := obj{"book_id": bookID}
+	total, err := r.store.conn.Collection(collectionBookRevisions).CountDocuments(ctx, filter)
+	if err != nil {
+		logError(ctx, "List CountDocuments", err)
+
+		return nil, 0, mongoErr(err)
+	}
+
+	results := []model.BookRevision{}
+	cursor, err := r.store.conn.Collection(collectionBookRevisions).Find(ctx, filter, options.Find().
+		SetSort(keys("book_id", "-revision")).
+		SetSkip(int64(offset)).
+		SetLimit(int64(limit)))
+	if err == nil {
+		err = cursor.All(ctx, &results)
+	}
+	if err != nil {
+		logError(ctx, "List Find", err)
+
+		return nil, 0, mongoErr(err)
+	}
+
+	return results, int(total), nil
+}
+
+func (r *RevisionsRepository) Find(ctx context.Context, bookID, revision uint64) (model.BookRevision, error) {
+	result := model.BookRevision{}
+	err := r.store.conn.Collection(collectionBookRevisions).
+		FindOne(ctx, obj{"book_id": bookID, "revision": revision}).
+		Decode(&result)
+	if err != nil {
+		logError(ctx, "Find FindOne", err)
+
+		return model.BookRevision{}, mongoErr(err)
+	}
+
+	return result, nil
+}
+
+// discard removes a revision whose change could not be written.
+func (r *RevisionsRepository) discard(ctx context.Context, ID uint64) {
+	_, err := r.store.conn.Collection(collectionBookRevisions).DeleteOne(ctx, obj{"_id": ID})
+	if err != nil {
+		logError(ctx, "discard DeleteOne", err)
+	}
+}
@@ -0,0 +1,105 @@
+package store
+
+import (
+	"bookService/model"
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+const (
+	collectionSessions = "sessions"
+)
+
+type (
+	SessionsRepository struct {
+		store          *MongoStore
+		collectionName string
+	}
+)
+
+func NewSessionsRepository(store *MongoStore) *SessionsRepository {
+	return &SessionsRepository{
+		store:          store,
+		collectionName: collectionSessions,
+	}
+}
+
+func (r *SessionsRepository) Insert(ctx context.Context, session model.Session) error {
+	_, err := r.store.conn.Collection(collectionSessions).InsertOne(ctx, session)
+	if err != nil {
+		logError(ctx, "Insert InsertOne", err)
+	}
+
+	return mongoErr(err)
+}
+
+func (r *SessionsRepository) Find(ctx context.Context, ID string) (model.Session, error) {
+	result := model.Session{}
+	err := r.store.conn.Collection(collectionSessions).FindOne(ctx, obj{"_id": ID}).Decode(&result)
+	if err != nil {
+		logError(ctx, "Find FindOne", err)
+
+		return model.Session{}, mongoErr(err)
+	}
+
+	return result, nil
+}
+
+// GetActiveByUser returns the sessions that are neither revoked nor expired,
+// most recently used first.
+func (r *SessionsRepository) GetActiveByUser(ctx context.Context, userID uint64) ([]model.Session, error) {
+	results := []model.Session{}
+	cursor, err := r.store.conn.Collection(collectionSessions).Find(ctx, obj{
+		"user_id":    userID,
+		"revoked_at": obj{"$exists": false},
+		"expires_at": obj{"$gt": time.Now()},
+	}, options.Find().SetSort(keys("-last_refresh_at")))
+	if err == nil {
+		err = cursor.All(ctx, &results)
+	}
+	if err != nil {
+		logError(ctx, "GetActiveByUser Find", err)
+	}
+
+	return results, mongoErr(err)
+}
+
+func (r *SessionsRepository) Touch(ctx context.Context, ID string, refreshedAt, expiresAt time.Time) error {
+	result, err := r.store.conn.Collection(collectionSessions).UpdateOne(ctx, obj{"_id": ID}, obj{"$set": obj{
+		"last_refresh_at": refreshedAt,
+		"expires_at":      expiresAt,
+	}})
+	if err != nil {
+		logError(ctx, "Touch UpdateOne", err)
+
+		return mongoErr(err)
+	}
+
+	return matched(result.MatchedCount)
+}
+
+func (r *SessionsRepository) Revoke(ctx context.Context, ID string) error {
+	result, err := r.store.conn.Collection(collectionSessions).
+		UpdateOne(ctx, obj{"_id": ID}, obj{"$set": obj{"revoked_at": time.Now()}})
+	if err != nil {
+		logError(ctx, "Revoke UpdateOne", err)
+
+		return mongoErr(err)
+	}
+
+	return matched(result.MatchedCount)
+}
+
+func (r *SessionsRepository) RevokeUser(ctx context.Context, userID uint64) error {
+	_, err := r.store.conn.Collection(collectionSessions).UpdateMany(ctx,
+		obj{"user_id": userID, "revoked_at": obj{"$exists": false}},
+		obj{"$set": obj{"revoked_at": time.Now()}},
+	)
+	if err != nil {
+		logError(ctx, "RevokeUser UpdateMany", err)
+	}
+
+	return mongoErr(err)
+}
@@ -0,0 +1,150 @@
+package store
+
+import (
+	"bookService/config"
+	"bookService/health"
+	"bookService/model"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrNotFound  = errors.New("not found")
+	ErrDuplicate = errors.New("duplicate key")
+	// ErrVersionConflict means the record changed since the version the
+	// caller read.
+	ErrVersionConflict = errors.New("version conflict")
+)
+
+// Store is a storage backend. The HTTP layer and auth.Middleware only ever
+// see these interfaces, so MongoStore and MemoryStore are interchangeable.
+type Store interface {
+	Books() BookStore
+	Revisions() RevisionStore
+	Users() UserStore
+	Authors() AuthorStore
+	Tokens() TokenStore
+	Sessions() SessionStore
+	Audit() AuditStore
+	// Checks are the readiness checks of the backend.
+	Checks() []health.Check
+	// Close releases the backend, waiting for in-flight operations until
+	// ctx is done.
+	Close(ctx context.Context) error
+}
+
+// New opens the backend selected by conf.Store.Backend and prepares it for
+// serving. Book and user operations of the returned store are measured.
+func New(ctx context.Context, conf *config.Config) (Store, error) {
+	var s Store
+	switch conf.Store.Backend {
+	case config.StoreBackendMongo:
+		mongo, err := NewMongoStore(conf)
+		if err != nil {
+			return nil, err
+		}
+		if err := mongo.prepare(ctx, conf.Mongo.MigrateOnStart); err != nil {
+			return nil, err
+		}
+		s = mongo
+	case config.StoreBackendMemory:
+		memory := NewMemoryStore()
+		memory.IDs = newIDGenerator(conf.Store)
+		s = memory
+	default:
+		return nil, fmt.Errorf("unknown store backend %q", conf.Store.Backend)
+	}
+
+	if conf.Store.SeedFixtures {
+		if err := SeedFixtures(ctx, s); err != nil {
+			return nil, err
+		}
+	}
+
+	return Instrument(s), nil
+}
+
+type BookStore interface {
+	GetAll(ctx context.Context) ([]model.Book, error)
+	Search(ctx context.Context, query model.BookQuery) (model.BookPage, error)
+	Find(ctx context.Context, bookID uint64) (model.Book, error)
+	Insert(ctx context.Context, item model.Book, ownerID uint64) (model.Book, error)
+	// Update applies only if the stored book is still at item.Version and
+	// returns the book with its new version.
+	Update(ctx context.Context, item model.Book, actorID uint64) (model.Book, error)
+	// Patch applies patch to the stored book atomically, retrying on
+	// concurrent changes. A version other than 0 must match the stored one.
+	Patch(ctx context.Context, ID, version, actorID uint64, patch BookPatch) (model.Book, error)
+	// Delete moves the book to the trash if it is at version.
+	Delete(ctx context.Context, ID, version, deletedBy uint64) error
+	// CountByContributor counts trashed books too, they may be restored.
+	CountByContributor(ctx context.Context, authorID uint64) (int, error)
+	// Trash lists the trashed books of the owner, of every owner if ownerID
+	// is 0, the most recently deleted first.
+	Trash(ctx context.Context, ownerID uint64, offset, limit int) ([]model.Book, int, error)
+	FindDeleted(ctx context.Context, ID uint64) (model.Book, error)
+	Restore(ctx context.Context, ID, actorID uint64) (model.Book, error)
+	// Purge permanently removes the books trashed before the given time.
+	// Their revisions are kept, the last one records the purge.
+	Purge(ctx context.Context, before time.Time) (int, error)
+}
+
+// RevisionStore keeps the history of the books. BookStore writes a revision
+// for every change it makes, revisions are never changed afterwards.
+type RevisionStore interface {
+	Insert(ctx context.Context, revision model.BookRevision) error
+	// List returns the revisions of a book, the newest first.
+	List(ctx context.Context, bookID uint64, offset, limit int) ([]model.BookRevision, int, error)
+	Find(ctx context.Context, bookID, revision uint64) (model.BookRevision, error)
+}
+
+// AuditStore is append only, there is no way to change or remove events.
+type AuditStore interface {
+	Insert(ctx context.Context, event model.AuditEvent) error
+	Search(ctx context.Context, query model.AuditQuery) ([]model.AuditEvent, error)
+}
+
+type UserStore interface {
+	GetAll(ctx context.Context) ([]model.User, error)
+	Search(ctx context.Context, login string, offset, limit int) ([]model.User, int, error)
+	Find(ctx context.Context, userID uint64) (model.User, error)
+	GetByLogin(ctx context.Context, login string) (*model.User, error)
+	// Insert stores a new user and returns it with its generated id.
+	Insert(ctx context.Context, item model.User) (model.User, error)
+	Update(ctx context.Context, item model.User) error
+	Delete(ctx context.Context, ID uint64) error
+	SaveRecoveryToken(ctx context.Context, userID uint64, recoveryToken string) error
+	VerifyRecoveryToken(ctx context.Context, recoveryToken string) (uint64, error)
+	SetPassword(ctx context.Context, userID uint64, hashedPassword string) error
+	SetRole(ctx context.Context, userID uint64, role model.Role) error
+	SetLocked(ctx context.Context, userID uint64, locked bool) error
+	RequirePasswordReset(ctx context.Context, userID uint64, recoveryToken string) error
+}
+
+type AuthorStore interface {
+	Search(ctx context.Context, name string, offset, limit int) ([]model.Author, int, error)
+	Find(ctx context.Context, authorID uint64) (model.Author, error)
+	Exist(ctx context.Context, IDs []uint64) (bool, error)
+	Insert(ctx context.Context, item model.Author) (model.Author, error)
+	Update(ctx context.Context, item model.Author) error
+	Delete(ctx context.Context, ID uint64) error
+}
+
+type TokenStore interface {
+	Insert(ctx context.Context, token model.RefreshToken) error
+	Find(ctx context.Context, ID string) (model.RefreshToken, error)
+	Use(ctx context.Context, ID string) (model.RefreshToken, error)
+	RevokeFamily(ctx context.Context, familyID string) error
+	RevokeUser(ctx context.Context, userID uint64) error
+}
+
+type SessionStore interface {
+	Insert(ctx context.Context, session model.Session) error
+	Find(ctx context.Context, ID string) (model.Session, error)
+	GetActiveByUser(ctx context.Context, userID uint64) ([]model.Session, error)
+	Touch(ctx context.Context, ID string, refreshedAt, expiresAt time.Time) error
+	Revoke(ctx context.Context, ID string) error
+	RevokeUser(ctx context.Context, userID uint64) error
+}
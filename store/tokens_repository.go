@@ -0,0 +1,98 @@
+package store
+
+import (
+	"bookService/model"
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+const (
+	collectionRefreshTokens = "refresh_tokens"
+)
+
+var ErrTokenReused = errors.New("refresh token reused")
+
+type (
+	TokensRepository struct {
+		store          *MongoStore
+		collectionName string
+	}
+)
+
+func NewTokensRepository(store *MongoStore) *TokensRepository {
+	return &TokensRepository{
+		store:          store,
+		collectionName: collectionRefreshTokens,
+	}
+}
+
+func (r *TokensRepository) Insert(ctx context.Context, token model.RefreshToken) error {
+	_, err := r.store.conn.Collection(collectionRefreshTokens).InsertOne(ctx, token)
+	if err != nil {
+		logError(ctx, "Insert InsertOne", err)
+	}
+
+	return mongoErr(err)
+}
+
+func (r *TokensRepository) Find(ctx context.Context, ID string) (model.RefreshToken, error) {
+	result := model.RefreshToken{}
+	err := r.store.conn.Collection(collectionRefreshTokens).FindOne(ctx, obj{"_id": ID}).Decode(&result)
+	if err != nil {
+		logError(ctx, "Find FindOne", err)
+
+		return model.RefreshToken{}, mongoErr(err)
+	}
+
+	return result, nil
+}
+
+// Use atomically marks the token as used. A token that was already used or
+// revoked is returned together with ErrTokenReused.
+func (r *TokensRepository) Use(ctx context.Context, ID string) (model.RefreshToken, error) {
+	result := model.RefreshToken{}
+	err := r.store.conn.Collection(collectionRefreshTokens).FindOneAndUpdate(ctx,
+		obj{"_id": ID, "used_at": obj{"$exists": false}, "revoked": false},
+		obj{"$set": obj{"used_at": time.Now()}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&result)
+	if err == nil {
+		return result, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		logError(ctx, "Use FindOneAndUpdate", err)
+
+		return model.RefreshToken{}, mongoErr(err)
+	}
+
+	result, err = r.Find(ctx, ID)
+	if err != nil {
+		return model.RefreshToken{}, err
+	}
+
+	return result, ErrTokenReused
+}
+
+func (r *TokensRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	_, err := r.store.conn.Collection(collectionRefreshTokens).
+		UpdateMany(ctx, obj{"family_id": familyID}, obj{"$set": obj{"revoked": true}})
+	if err != nil {
+		logError(ctx, "RevokeFamily UpdateMany", err)
+	}
+
+	return mongoErr(err)
+}
+
+func (r *TokensRepository) RevokeUser(ctx context.Context, userID uint64) error {
+	_, err := r.store.conn.Collection(collectionRefreshTokens).
+		UpdateMany(ctx, obj{"user_id": userID}, obj{"$set": obj{"revoked": true}})
+	if err != nil {
+		logError(ctx, "RevokeUser UpdateMany", err)
+	}
+
+	return mongoErr(err)
+}
@@ -0,0 +1,29 @@
+package store
+
+import (
+	"bookService/logging"
+	"context"
+	"time"
+)
+
+// StartTrashPurge permanently removes the books that have been in the trash
+// for longer than retention, checking every interval until ctx is done.
+func StartTrashPurge(ctx context.Context, books BookStore, retention, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		n, err := books.Purge(ctx, time.Now().Add(-retention))
+		if err != nil {
+			logError(ctx, "StartTrashPurge Purge", err)
+		} else if n > 0 {
+			logging.FromContext(ctx).Info("StartTrashPurge purged", "books", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -2,12 +2,11 @@ package store
 
 import (
 	"bookService/model"
-	"fmt"
-	"log"
+	"context"
+	"regexp"
 
-	ai "github.com/night-codes/mgo-ai"
-	"gopkg.in/mgo.v2"
-	"gopkg.in/mgo.v2/bson"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
 )
 
 const (
@@ -28,127 +27,150 @@ func NewUsersRepository(store *MongoStore) *UsersRepository {
 	}
 }
 
-func (r *UsersRepository) GetAll() ([]model.User, error) {
+func (r *UsersRepository) GetAll(ctx context.Context) ([]model.User, error) {
 	results := []model.User{}
-	err := r.store.conn.C(collectionUsers).Find(obj{}).All(&results)
+	cursor, err := r.store.conn.Collection(collectionUsers).Find(ctx, obj{})
+	if err == nil {
+		err = cursor.All(ctx, &results)
+	}
 	if err != nil {
-		log.Println("GetAll Find err: ", err)
+		logError(ctx, "GetAll Find", err)
 	}
 
-	return results, err
+	return results, mongoErr(err)
 }
 
-func (r *UsersRepository) Find(userID uint64) (model.User, error) {
-	result := model.User{}
-	err := r.store.conn.C(collectionUsers).FindId(userID).One(&result)
+// Search pages through the users whose login contains login, ordered by id.
+func (r *UsersRepository) Search(ctx context.Context, login string, offset, limit int) ([]model.User, int, error) {
+	filter := obj{}
+	if login != "" {
+		filter["login"] = bson.Regex{Pattern: regexp.QuoteMeta(login), Options: "i"}
+	}
+
+	collection := r.store.conn.Collection(collectionUsers)
+	total, err := collection.CountDocuments(ctx, filter)
 	if err != nil {
-		log.Println("Find FindId err: ", err)
+		logError(ctx, "Search CountDocuments", err)
 
-		return model.User{}, err
+		return nil, 0, mongoErr(err)
 	}
 
-	return result, nil
-}
-
-func (r *UsersRepository) Insert(item model.User) error {
-	ai.Connect(r.store.conn.C("ai"))
-	item.ID = ai.Next(collectionUsers)
-	err := r.store.conn.C(collectionUsers).Insert(item)
+	results := []model.User{}
+	cursor, err := collection.Find(ctx, filter,
+		options.Find().SetSort(keys("_id")).SetSkip(int64(offset)).SetLimit(int64(limit)))
+	if err == nil {
+		err = cursor.All(ctx, &results)
+	}
 	if err != nil {
-		log.Println("Insert Insert err: ", err)
+		logError(ctx, "Search Find", err)
+
+		return nil, 0, mongoErr(err)
 	}
 
-	return err
+	return results, int(total), nil
 }
 
-func (r *UsersRepository) Update(item model.User) error {
-	err := r.store.conn.C(collectionUsers).UpdateId(item.ID, obj{"$set": item})
+func (r *UsersRepository) Find(ctx context.Context, userID uint64) (model.User, error) {
+	result := model.User{}
+	err := r.store.conn.Collection(collectionUsers).FindOne(ctx, obj{"_id": userID}).Decode(&result)
 	if err != nil {
-		log.Println("Update UpdateId err: ", err)
+		logError(ctx, "Find FindOne", err)
+
+		return model.User{}, mongoErr(err)
 	}
 
-	return err
+	return result, nil
 }
 
-func (r *UsersRepository) Delete(ID uint64) error {
-	err := r.store.conn.C(collectionUsers).Remove(obj{"_id": ID})
+func (r *UsersRepository) Insert(ctx context.Context, item model.User) (model.User, error) {
+	item.ID = r.store.IDs.NewID()
+	_, err := r.store.conn.Collection(collectionUsers).InsertOne(ctx, item)
 	if err != nil {
-		log.Println("Delete Remove err: ", err)
+		logError(ctx, "Insert InsertOne", err)
+
+		return model.User{}, mongoErr(err)
 	}
 
-	return err
+	return item, nil
 }
 
-func (r *UsersRepository) GetByLogin(login string) (*model.User, error) {
-	result := &model.User{}
-	err := r.store.conn.C(collectionUsers).Find(obj{"login": login}).One(result)
-	if err != nil {
-		log.Println("GetByLogin Find err: ", err)
-	}
-
-	return result, err
+func (r *UsersRepository) Update(ctx context.Context, item model.User) error {
+	return r.set(ctx, "Update", item.ID, item)
 }
 
-func (r *UsersRepository) SaveRecoveryToken(userID uint64, recoveryToken string) error {
-	user, err := r.Find(userID)
+func (r *UsersRepository) Delete(ctx context.Context, ID uint64) error {
+	result, err := r.store.conn.Collection(collectionUsers).DeleteOne(ctx, obj{"_id": ID})
 	if err != nil {
-		log.Println("SaveRecoveryToken Find err: ", err)
+		logError(ctx, "Delete DeleteOne", err)
 
-		return err
+		return mongoErr(err)
 	}
 
-	user.RecoveryToken = recoveryToken
-
-	if err := r.Update(user); err != nil {
-		log.Println("SaveRecoveryToken Update err: ", err)
+	return matched(result.DeletedCount)
+}
 
-		return err
+func (r *UsersRepository) GetByLogin(ctx context.Context, login string) (*model.User, error) {
+	result := &model.User{}
+	err := r.store.conn.Collection(collectionUsers).FindOne(ctx, obj{"login": login}).Decode(result)
+	if err != nil {
+		logError(ctx, "GetByLogin FindOne", err)
 	}
 
-	return nil
+	return result, mongoErr(err)
 }
 
-func (r *UsersRepository) SetPassword(userID uint64, hashedPassword string) error {
-	user, err := r.Find(userID)
-	if err != nil {
-		log.Println("SetPassword Find err: ", err)
-
-		return err
-	}
+func (r *UsersRepository) SaveRecoveryToken(ctx context.Context, userID uint64, recoveryToken string) error {
+	return r.set(ctx, "SaveRecoveryToken", userID, obj{"recoveryToken": recoveryToken})
+}
 
-	user.Password = hashedPassword
+func (r *UsersRepository) SetPassword(ctx context.Context, userID uint64, hashedPassword string) error {
+	return r.set(ctx, "SetPassword", userID, obj{
+		"password":                hashedPassword,
+		"recoveryToken":           "",
+		"password_reset_required": false,
+	})
+}
 
-	if err := r.Update(user); err != nil {
-		log.Println("SetPassword Update err: ", err)
+func (r *UsersRepository) SetRole(ctx context.Context, userID uint64, role model.Role) error {
+	return r.set(ctx, "SetRole", userID, obj{"role": role})
+}
 
-		return err
-	}
+func (r *UsersRepository) SetLocked(ctx context.Context, userID uint64, locked bool) error {
+	return r.set(ctx, "SetLocked", userID, obj{"locked": locked})
+}
 
-	return nil
+// RequirePasswordReset blocks sign in until the password is set again with
+// the given recovery token.
+func (r *UsersRepository) RequirePasswordReset(ctx context.Context, userID uint64, recoveryToken string) error {
+	return r.set(ctx, "RequirePasswordReset", userID, obj{
+		"password_reset_required": true,
+		"recoveryToken":           recoveryToken,
+	})
 }
 
-func (r *UsersRepository) VerifyRecoveryToken(recoveryToken string) (uint64, error) {
-	user, err := r.getUserByRecoveryToken(recoveryToken)
+func (r *UsersRepository) VerifyRecoveryToken(ctx context.Context, recoveryToken string) (uint64, error) {
+	if recoveryToken == "" {
+		return 0, ErrNotFound
+	}
+
+	user := model.User{}
+	err := r.store.conn.Collection(collectionUsers).FindOne(ctx, obj{"recoveryToken": recoveryToken}).Decode(&user)
 	if err != nil {
-		log.Println("VerifyRecoveryToken getUserByRecoveryToken err: ", err)
+		logError(ctx, "VerifyRecoveryToken FindOne", err)
 
-		return 0, err
+		return 0, mongoErr(err)
 	}
 
 	return user.ID, nil
 }
 
-func (r *UsersRepository) getUserByRecoveryToken(recoveryToken string) (*model.User, error) {
-	user := &model.User{}
-	err := r.store.conn.C(collectionUsers).Find(bson.M{"recoveryToken": recoveryToken}).One(user)
+func (r *UsersRepository) set(ctx context.Context, op string, userID uint64, fields interface{}) error {
+	result, err := r.store.conn.Collection(collectionUsers).UpdateOne(ctx, obj{"_id": userID}, obj{"$set": fields})
 	if err != nil {
-		log.Println("getUserByRecoveryToken Find err: ", err)
-		if err == mgo.ErrNotFound {
-			return nil, fmt.Errorf("user not found")
-		}
+		logError(ctx, op+" UpdateOne", err)
 
-		return nil, err
+		return mongoErr(err)
 	}
 
-	return user, nil
+	return matched(result.MatchedCount)
 }
@@ -0,0 +1,51 @@
+package tracing
+
+import (
+	"bookService/config"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestSetupWritesSpansToFile(t *testing.T) {
+	defer otel.SetTracerProvider(otel.GetTracerProvider())
+	path := filepath.Join(t.TempDir(), "spans.json")
+
+	shutdown, err := Setup(context.Background(), config.TraceConfig{
+		Exporter:    config.TraceExporterFile,
+		File:        path,
+		SampleRatio: 1,
+	})
+	assert.NoError(t, err)
+	_, span := Start(context.Background(), "test.span")
+	span.End()
+	assert.NoError(t, shutdown(context.Background()))
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"Name":"test.span"`)
+}
+
+func TestEndRecordsError(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	_, span := provider.Tracer("test").Start(context.Background(), "failing")
+	End(span, errors.New("boom"))
+	_, span = provider.Tracer("test").Start(context.Background(), "passing")
+	End(span, nil)
+
+	spans := exporter.GetSpans()
+	assert.Len(t, spans, 2)
+	assert.Equal(t, codes.Error, spans[0].Status.Code)
+	assert.Equal(t, "boom", spans[0].Status.Description)
+	assert.Equal(t, codes.Unset, spans[1].Status.Code)
+}